@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// skipPattern is the -skip pattern excluding the flaky tests from all the test
+// runs, empty if there are none.
+var skipPattern string
+
+// detectFlaky runs the tests of the package runs times and returns the top
+// level tests whose outcome changed between runs.
+func detectFlaky(cfg config, runs int) []string {
+	failed := make(map[string]bool)
+	passed := make(map[string]bool)
+	for i := 0; i < runs; i++ {
+		// -count=1 disables the test cache.
+		cmd := exec.Command("go", testArgs(0, "-count=1", "-json", cfg.pkg)...)
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Run()
+		run := parseTestEvents(out.Bytes())
+		for _, test := range run.failed {
+			failed[test] = true
+		}
+		for _, test := range run.passed {
+			passed[test] = true
+		}
+	}
+
+	// -skip matches subtests level by level, skip their top level test.
+	seen := make(map[string]bool)
+	var flaky []string
+	for test := range failed {
+		if !passed[test] {
+			continue
+		}
+		test = strings.SplitN(test, "/", 2)[0]
+		if !seen[test] {
+			seen[test] = true
+			flaky = append(flaky, test)
+		}
+	}
+	sort.Strings(flaky)
+	return flaky
+}
+
+// excludeFlaky sets skipPattern to skip the flaky tests.
+func excludeFlaky(flaky []string) {
+	if len(flaky) == 0 {
+		return
+	}
+	quoted := make([]string, len(flaky))
+	for i, test := range flaky {
+		quoted[i] = regexp.QuoteMeta(test)
+	}
+	skipPattern = "^(" + strings.Join(quoted, "|") + ")$"
+	fmt.Fprintf(os.Stderr, "skipping flaky tests: %s\n", strings.Join(flaky, ", "))
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// failingInput matches the path of the input written by `go test -fuzz` when
+// the fuzz target fails.
+var failingInput = regexp.MustCompile(`Failing input written to (\S+)`)
+
+// fuzzTargets returns the names of the fuzz targets of the package.
+func fuzzTargets(lp *loadedPackage) []string {
+	var targets []string
+	for _, pkg := range lp.pkgs {
+		for name, file := range pkg.Files {
+			if !strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Fuzz") || len(fn.Type.Params.List) != 1 {
+					continue
+				}
+				if star, ok := fn.Type.Params.List[0].Type.(*ast.StarExpr); ok {
+					if sel, ok := star.X.(*ast.SelectorExpr); ok && sel.Sel.Name == "F" {
+						targets = append(targets, fn.Name.Name)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// copyFuzzCorpus copies the seed corpus of the fuzz targets in testdata/fuzz of
+// the package in src to dst.
+func copyFuzzCorpus(src, dst string) error {
+	root := filepath.Join(src, "testdata", "fuzz")
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(dst, rel), b, 0644)
+	})
+}
+
+// fuzz runs the fuzz targets against the alive mutant for -fuzz-time each. The
+// inputs making a target fail are run against the original source, src, and
+// the first one it passes is saved in the seed corpus of the package. It
+// returns the target killing the mutant and the path of the input, empty if
+// the mutant survives. Higher-order mutants are not fuzzed.
+func (t *tester) fuzz(baseName string, src []byte) (string, string) {
+	if t.higherOrder {
+		return "", ""
+	}
+	for _, target := range t.fuzzTargets {
+		cmd := exec.Command("go", testArgs(0, "-run", "^$", "-fuzz", "^"+target+"$", "-fuzztime", fuzzTimeFlag.String())...)
+		cmd.Dir = t.mutantDir
+		cmd.Env = t.env
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		if cmd.Run() == nil {
+			continue
+		}
+		m := failingInput.FindSubmatch(out.Bytes())
+		if m == nil {
+			// the fuzz test didn't run, eg. it doesn't build.
+			continue
+		}
+		input := filepath.Join(t.mutantDir, string(m[1]))
+		if path, ok := t.verifyInput(baseName, src, target, input); ok {
+			return target, path
+		}
+	}
+	return "", ""
+}
+
+// verifyInput runs the target with the input against the original source and,
+// if it passes, saves the input to the seed corpus of the original package. It
+// removes the input from the mutant directory and returns where it was saved.
+func (t *tester) verifyInput(baseName string, src []byte, target, input string) (string, bool) {
+	defer os.Remove(input)
+
+	orig, err := ioutil.ReadFile(filepath.Join(t.originalDir, baseName))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %s\n", baseName, err.Error())
+		return "", false
+	}
+	mutantFile := filepath.Join(t.mutantDir, baseName)
+	if err := ioutil.WriteFile(mutantFile, orig, 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %s\n", baseName, err.Error())
+		return "", false
+	}
+	cmd := exec.Command("go", testArgs(t.timeout, "-run", "^"+target+"$/^"+filepath.Base(input)+"$")...)
+	cmd.Dir = t.mutantDir
+	cmd.Env = t.env
+	passed := cmd.Run() == nil
+	if err := ioutil.WriteFile(mutantFile, src, 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %s\n", baseName, err.Error())
+		return "", false
+	}
+	if !passed {
+		// the input makes the original fail too, it doesn't tell the mutant
+		// apart.
+		return "", false
+	}
+
+	b, err := ioutil.ReadFile(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %s\n", input, err.Error())
+		return "", false
+	}
+	rel := filepath.Join("testdata", "fuzz", target, filepath.Base(input))
+	dst := filepath.Join(t.originalDir, rel)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return "", false
+	}
+	if err := ioutil.WriteFile(dst, b, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return "", false
+	}
+	return rel, true
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hydroflame/godzilla"
+	"golang.org/x/tools/cover"
+)
+
+// homTester tests the higher-order mutants of a file. This makes *homTester
+// implement the godzilla.HigherOrderTester interface.
+type homTester struct {
+	tester
+	// the status of the first-order mutants, indexed by ID.
+	statuses map[string]string
+	homs     []homReport
+}
+
+// isKilled returns true if status is the status of a killed mutant.
+func isKilled(status string) bool {
+	return status == "killed" || status == "timedout" || status == "fuzzed"
+}
+
+func (t *homTester) TestHigherOrder(hom godzilla.HigherOrderMutant) {
+	t.test()
+	if t.status == "" {
+		return
+	}
+	baseName := filepath.Base(t.astFileName)
+	r := homReport{
+		First:  mutantID(hom.First.Mutator, baseName, t.fset.Position(hom.First.Node.Pos()), hom.First.Number),
+		Second: mutantID(hom.Second.Mutator, baseName, t.fset.Position(hom.Second.Node.Pos()), hom.Second.Number),
+		Status: t.status,
+	}
+	if t.status == "alive" && isKilled(t.statuses[r.First]) && isKilled(t.statuses[r.Second]) {
+		r.Masking = true
+		r.Diff = string(t.diff(baseName))
+		if !*diffonlyinvalid {
+			fmt.Printf("higher-order mutant %s + %s is alive while both its mutants are killed\n", r.First, r.Second)
+			os.Stdout.WriteString(r.Diff)
+		}
+	}
+	t.homs = append(t.homs, r)
+}
+
+// MutateHigherOrder tests the higher-order mutants of the files it gets from
+// the given channel.
+func (w worker) MutateHigherOrder(c chan string, mutators []string, statuses map[string]string, homs chan []homReport) {
+	lp, err := loadPackage(w.originalDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+	if err := lp.writeFiles(w.mutantDir); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	scope := godzilla.SameFunction
+	if *homScopeFlag == "file" {
+		scope = godzilla.AnyFunction
+	}
+
+	original := w.originalHash()
+	for name := range c {
+		file := lp.pkg.Files[name]
+		parseInfo := w.parseInfo(lp, name, file)
+		t := &homTester{
+			tester:   w.tester(lp, name, file, original),
+			statuses: statuses,
+		}
+		t.quiet = true
+		t.higherOrder = true
+
+		// the pairs sampled only depend on the file and the seed.
+		h := fnv.New64a()
+		h.Write([]byte(filepath.Base(name)))
+		rng := rand.New(rand.NewSource(int64(h.Sum64()) ^ *seedFlag))
+		sample := func(first, second godzilla.FirstOrderMutant) bool {
+			return rng.Float64() < *homFlag
+		}
+
+		mutants := godzilla.FirstOrderMutants(parseInfo, mutators)
+		godzilla.TestHigherOrder(parseInfo, mutants, scope, sample, t)
+		homs <- t.homs
+	}
+}
+
+// runHigherOrder tests the higher-order mutants sampled with -hom, statuses are
+// the status of the first-order mutants indexed by ID.
+func runHigherOrder(cfg config, coverprofiles []*cover.Profile, tmpDir string, hashes *hashSet, statuses map[string]string) []homReport {
+	lp, err := loadPackage(cfg.pkgFull)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil
+	}
+	c := make(chan string, len(lp.pkg.Files))
+	for name := range lp.pkg.Files {
+		if !strings.HasSuffix(name, "_test.go") {
+			c <- name
+		}
+	}
+	close(c)
+
+	// the mutants are sampled in the same order in every run.
+	mutators := append([]string(nil), cfg.mutations...)
+	sort.Strings(mutators)
+
+	var wg sync.WaitGroup
+	results := make(chan []homReport)
+	for n := 0; n < runtime.NumCPU(); n++ {
+		workdir := filepath.Join(tmpDir, "godzilla"+strconv.Itoa(n))
+		mutantDir := filepath.Join(workdir, "src", cfg.pkg)
+		if err := os.MkdirAll(mutantDir, 0755); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		w := worker{
+			mutantDir:     mutantDir,
+			originalDir:   cfg.pkgFull,
+			coverprofiles: coverprofiles,
+			timeout:       cfg.timeout,
+			env:           append(os.Environ(), "GOPATH="+workdir+string(filepath.ListSeparator)+cfg.gopath),
+			binary:        filepath.Join(workdir, "mutant.test"),
+			hashes:        hashes,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.MutateHigherOrder(c, mutators, statuses, results)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var homs []homReport
+	for r := range results {
+		homs = append(homs, r...)
+	}
+	sort.Slice(homs, func(i, j int) bool {
+		if homs[i].First != homs[j].First {
+			return homs[i].First < homs[j].First
+		}
+		return homs[i].Second < homs[j].Second
+	})
+
+	var killed, alive, masking int
+	for _, h := range homs {
+		switch {
+		case isKilled(h.Status):
+			killed++
+		case h.Status == "alive":
+			alive++
+		}
+		if h.Masking {
+			masking++
+		}
+	}
+	fmt.Printf("higher-order mutants: %d tested (%d killed, %d alive, %d masking)\n", len(homs), killed, alive, masking)
+	return homs
+}
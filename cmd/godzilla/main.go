@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"flag"
 	"fmt"
 	"go/ast"
@@ -24,14 +25,43 @@ import (
 
 	"github.com/hydroflame/godzilla"
 	"golang.org/x/tools/cover"
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 var (
 	diffonlyinvalid = flag.Bool("diffonlyinvalid", false, "debug flag, this prints only the invalid builds produced")
 	mutationFlag    = flag.String("mutations", "", "the list of mutation to execute, comma separated")
 	helpFlag        = flag.Bool("help", false, "Display help message")
+	raceFlag        = flag.Bool("race", false, "run the tests with the race detector enabled")
+	timeoutFlag     = flag.Duration("timeout", 0, "the time after which the tests of a mutant are considered hanging")
+	tceFlag         = flag.Bool("tce", true, "detect equivalent and duplicate mutants by comparing their test binaries")
+	weakFlag        = flag.Bool("weak", false, "run the weak mutation analysis before testing the mutants")
+	weakOnlyFlag    = flag.Bool("weakonly", false, "only run the weak mutation analysis")
+	filtersFlag     = flag.String("filters", "", "the list of equivalent mutant filters to run, comma separated, none to disable them")
+	filteredFlag    = flag.Bool("filtered", false, "print the mutants discarded by the filters and why")
+	callsFlag       = flag.String("calls", "", "extra call replacements for callrepl, comma separated list of name=replacement")
+	reportFlag      = flag.String("report", "", "write the JSON report with the kill matrix to this file")
+	fullMatrixFlag  = flag.Bool("fullmatrix", false, "run all the tests against every mutant instead of stopping at the first failure")
+	homFlag         = flag.Float64("hom", 0, "the rate of the pairs of mutants combined into higher-order mutants, 0 disables them")
+	sampleFlag      = flag.Float64("sample", 0, "the rate of the mutants tested, sampled by mutator and file")
+	maxMutantsFlag  = flag.Int("max-mutants", 0, "the maximum number of mutants tested, sampled by mutator and file")
+	seedFlag        = flag.Int64("seed", 1, "the seed of the random samples")
+	flakyRunsFlag   = flag.Int("flakyruns", 0, "run the tests this number of times before mutating to detect and skip flaky tests")
+	confirmFlag     = flag.Int("confirmkills", 0, "re-run the tests of killed mutants this number of times, mutants passing them are flaky")
+	fuzzTimeFlag    = flag.Duration("fuzz-time", 0, "run every fuzz target this long against the alive mutants, 0 disables fuzzing")
+	homScopeFlag    = flag.String("homscope", "func", "the mutants combined into higher-order mutants, func for the same function, file for the same file")
 )
 
+// buildFlags are the flags making test binaries reproducible, so that identical
+// programs produce identical binaries.
+// The build ID depends on the source, it has to be left out for equivalent
+// mutants to compile to the binary of the original package.
+var buildFlags = []string{"-trimpath", "-ldflags=-s -w -buildid="}
+
+// minTimeout is the minimum time given to the tests of a mutant when the
+// timeout is derived from the duration of the original tests.
+const minTimeout = 5 * time.Second
+
 type config struct {
 	// The importable name of the package to irradiate.
 	pkg string
@@ -42,7 +72,83 @@ type config struct {
 	// A reference to the user gopath
 	gopath string
 
-	mutations []godzilla.Mutator
+	// the names of the mutators to run.
+	mutations []string
+
+	// The time after which the tests of a mutant are stopped.
+	timeout time.Duration
+}
+
+// testArgs returns the arguments passed to `go test` for every test run,
+// followed by args. A zero timeout uses the go test default.
+func testArgs(timeout time.Duration, args ...string) []string {
+	a := []string{"test", "-short"}
+	if *raceFlag {
+		a = append(a, "-race")
+	}
+	if timeout > 0 {
+		a = append(a, "-timeout", timeout.String())
+	}
+	if skipPattern != "" {
+		a = append(a, "-skip", skipPattern)
+	}
+	return append(a, args...)
+}
+
+// compileArgs returns the arguments passed to `go test` to compile the test
+// binary to out.
+func compileArgs(out string) []string {
+	a := []string{"test", "-c", "-o", out}
+	if *raceFlag {
+		a = append(a, "-race")
+	}
+	return append(a, buildFlags...)
+}
+
+// compileTest compiles the test binary of the package in dir to binary, it
+// returns the output of the compiler.
+func compileTest(dir, binary string, env []string) ([]byte, error) {
+	// without a build ID a previous binary could be mistaken for the new
+	// one, eg. when there are no test files and no binary is written.
+	os.Remove(binary)
+	cmd := exec.Command("go", compileArgs(binary)...)
+	cmd.Dir = dir
+	cmd.Env = env
+	return cmd.CombinedOutput()
+}
+
+// binaryArgs returns the arguments of the compiled test binary equivalent to
+// testArgs.
+func binaryArgs(timeout time.Duration) []string {
+	a := []string{"-test.short"}
+	if timeout > 0 {
+		a = append(a, "-test.timeout", timeout.String())
+	}
+	if skipPattern != "" {
+		a = append(a, "-test.skip", skipPattern)
+	}
+	return a
+}
+
+// mutantTestArgs returns the arguments of `go test` running the tests of a
+// mutant, the per test outcomes are printed as JSON events.
+func mutantTestArgs(timeout time.Duration) []string {
+	if *fullMatrixFlag {
+		return testArgs(timeout, "-json")
+	}
+	return testArgs(timeout, "-json", "-failfast")
+}
+
+// mutantBinaryArgs returns the arguments of `go tool` running the compiled
+// test binary of a mutant like mutantTestArgs.
+func mutantBinaryArgs(binary string, timeout time.Duration) []string {
+	// test2json needs the test events of -test.v=test2json to tell the output
+	// of the tests apart.
+	a := append([]string{"tool", "test2json", binary, "-test.v=test2json"}, binaryArgs(timeout)...)
+	if !*fullMatrixFlag {
+		a = append(a, "-test.failfast")
+	}
+	return a
 }
 
 func getRunConfig() config {
@@ -56,6 +162,10 @@ func getRunConfig() config {
 			}
 			mutatorsHelp += fmt.Sprintf("			%s: %s\n", name, desc.Description)
 		}
+		var filtersHelp string
+		for name := range godzilla.Filters {
+			filtersHelp += fmt.Sprintf("			%s\n", name)
+		}
 		fmt.Printf(`
 godzilla is a mutestion testing tool for go packages. The goal of mutation
 testing is to give a metric for the quality of your test suite. godzilla will
@@ -76,6 +186,8 @@ maximum number of equivalent mutant.
 Usage of godzilla:
 	godzilla [flags] # runs on package in current directory
 	godzilla [flags] package # runs on that package in the $GOPATH
+	godzilla minimize report.json # prints a minimal subset of the tests
+		# killing the same mutants, from a report written with -report
 Flags:
 	-help
 		display this message
@@ -83,7 +195,81 @@ Flags:
 		comma separated list of mutations to execute, (default to all mutators)
 		The available mutations are:
 %s
-`, mutatorsHelp)
+	-timeout duration
+		the time after which the tests of a mutant are stopped and the mutant
+		reported as timed out (default to 10 times the duration of the
+		original tests, at least 5s)
+	-race
+		run the tests with the race detector enabled, this is most useful with
+		the concurrency mutators (lockrm, rlock, wgrm, gosync, closerm, chancap)
+	-tce
+		compile the test binary of every mutant and compare it with the one of
+		the original package and of the other mutants, mutants with identical
+		binaries are reported as equivalent or duplicate without running the
+		tests (default true, disable with -tce=false)
+	-weak
+		before testing the mutants, instrument the expression mutants so that
+		a single run of the tests tells whether each mutant is reached and
+		infected, ie. evaluates to a different value than the original
+		expression. Mutants that are infected but not killed are reported as
+		assertion gaps.
+	-weakonly
+		only run the weak mutation analysis
+	-filters string
+		comma separated list of the static filters discarding equivalent
+		mutants before they are tested, none disables them (default to all
+		filters). The available filters are:
+%s
+	-filtered
+		print the mutants discarded by the filters and why
+	-calls string
+		comma separated list of extra call replacements for callrepl, in the
+		form name=replacement where name is the full name of the function
+		(eg. example.com/pkg.IsValid or (*example.com/pkg.T).Min) and
+		replacement the name of the function replacing it, ! to negate
+		the result of the call or < to swap the parameters of the function
+		literal passed as its last argument, like the less function of
+		sort.Slice.
+	-report string
+		write a JSON report to this file, it holds the kill matrix: for every
+		mutant its status and the tests that killed it and that passed.
+	-fullmatrix
+		run all the tests against every mutant instead of stopping at the
+		first failing test, so the report tells every test killing a mutant.
+		The minimal mutants, that aren't redundant with another mutant, are
+		marked in the report and their score is printed.
+	-sample float
+		only test a random sample of the mutants, the float is the rate of
+		mutants tested (eg. 0.2). Every mutator and file gets its share of the
+		sample and the score of all the mutants is estimated with a 95%%
+		confidence interval.
+	-max-mutants int
+		test at most this number of mutants, sampled like with -sample
+	-seed int
+		the seed of the random samples of -sample, -max-mutants and -hom,
+		the same seed samples the same mutants (default 1)
+	-flakyruns int
+		before mutating, run the tests this number of times, at least 2, the
+		tests that both fail and pass are flaky and skipped in every run
+		(needs Go 1.20)
+	-confirmkills int
+		re-run the tests of killed mutants this number of times, mutants that
+		pass one of the re-runs are reported flaky and not counted in the
+		score. Timed out mutants are not re-run
+	-fuzz-time duration
+		run every fuzz target of the package this long against each alive
+		mutant. An input making a target fail is run against the original
+		package, if it passes there the mutant is killed by fuzzing and the
+		input is saved in the seed corpus of the package, in testdata/fuzz.
+	-hom float
+		after testing the mutants, combine pairs of mutants into higher-order
+		mutants, the float is the rate of pairs sampled (eg. 0.1). The
+		higher-order mutants that survive while both their mutants are killed
+		are reported, one mutation masks the other.
+	-homscope string
+		the pairs of mutants combined by -hom, func for mutants of the same
+		function and file for mutants of the same file (default func)
+`, mutatorsHelp, filtersHelp)
 		os.Exit(0)
 	}
 
@@ -112,34 +298,89 @@ Flags:
 		pkg = wd[len(gopath)+len(`/src/`):]
 	}
 
-	var mtrs []godzilla.Mutator
+	var mtrs []string
 	if *mutationFlag == "" {
-		for _, desc := range godzilla.Mutators {
-			mtrs = append(mtrs, desc.M)
+		for name := range godzilla.Mutators {
+			mtrs = append(mtrs, name)
 		}
 	} else {
 		names := strings.Split(*mutationFlag, ",")
 		for _, name := range names {
-			desc, ok := godzilla.Mutators[name]
-			if !ok {
+			if _, ok := godzilla.Mutators[name]; !ok {
 				fmt.Printf("Unknown mutator: %s\n", name)
 				os.Exit(1)
 			}
-			mtrs = append(mtrs, desc.M)
+			mtrs = append(mtrs, name)
+		}
+	}
+
+	if *filtersFlag != "" {
+		filters := make(map[string]godzilla.Filter)
+		if *filtersFlag != "none" {
+			for _, name := range strings.Split(*filtersFlag, ",") {
+				filter, ok := godzilla.Filters[name]
+				if !ok {
+					fmt.Printf("Unknown filter: %s\n", name)
+					os.Exit(1)
+				}
+				filters[name] = filter
+			}
+		}
+		godzilla.Filters = filters
+	}
+
+	if *callsFlag != "" {
+		for _, repl := range strings.Split(*callsFlag, ",") {
+			i := strings.LastIndex(repl, "=")
+			if i <= 0 || i == len(repl)-1 {
+				fmt.Printf("Invalid call replacement: %s\n", repl)
+				os.Exit(1)
+			}
+			name, to := repl[:i], repl[i+1:]
+			switch to {
+			case "!":
+				godzilla.CallReplacements[name] = godzilla.CallReplacement{Negate: true}
+			case "<":
+				godzilla.CallReplacements[name] = godzilla.CallReplacement{InvertLess: true}
+			default:
+				godzilla.CallReplacements[name] = godzilla.CallReplacement{Name: to}
+			}
 		}
 	}
 
+	if *homFlag < 0 || *homFlag > 1 {
+		fmt.Printf("Invalid -hom rate: %v\n", *homFlag)
+		os.Exit(1)
+	}
+	if *sampleFlag < 0 || *sampleFlag > 1 {
+		fmt.Printf("Invalid -sample rate: %v\n", *sampleFlag)
+		os.Exit(1)
+	}
+	if *homScopeFlag != "func" && *homScopeFlag != "file" {
+		fmt.Printf("Invalid -homscope: %s\n", *homScopeFlag)
+		os.Exit(1)
+	}
+	// a single run can't tell flaky tests apart.
+	if *flakyRunsFlag == 1 || *flakyRunsFlag < 0 {
+		fmt.Printf("Invalid -flakyruns: %d, at least 2 runs are needed\n", *flakyRunsFlag)
+		os.Exit(1)
+	}
+
 	return config{
 		pkg:       pkg,
 		gopath:    gopath,
 		pkgFull:   filepath.Join(gopath, "src", pkg),
 		mutations: mtrs,
+		timeout:   *timeoutFlag,
 	}
 }
 
 // sanityCheck verifies that the pkg we are trying to mutest compiles and that
-// the tests pass.
-func sanityCheck(cfg config) {
+// the tests pass, after excluding the flaky tests with -flakyruns. It returns
+// how long the tests took and the flaky tests.
+func sanityCheck(cfg config) (time.Duration, []string) {
+	var elapsed time.Duration
+	var flaky []string
 	{ // verify we have the diff program
 		if _, err := exec.LookPath("diff"); err != nil {
 			fmt.Fprintln(os.Stderr, "the program `diff` was not found in path")
@@ -162,14 +403,21 @@ func sanityCheck(cfg config) {
 			os.Exit(1)
 		}
 	}
+	if *flakyRunsFlag > 1 { // find and skip flaky tests
+		flaky = detectFlaky(cfg, *flakyRunsFlag)
+		excludeFlaky(flaky)
+	}
 	{ // verify tests pass
-		cmd := exec.Command("go", "test", "-short", cfg.pkg)
+		args := testArgs(0, cfg.pkg)
+		cmd := exec.Command("go", args...)
 		cmd.Stderr = os.Stderr
+		start := time.Now()
 		err := cmd.Run()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "FAILED: go test -short %s\n", cfg.pkg)
+			fmt.Fprintf(os.Stderr, "FAILED: go %s\n", strings.Join(args, " "))
 			os.Exit(1)
 		}
+		elapsed = time.Since(start)
 	}
 	{ // verify that everything is already gofmt -s before
 		finfos, err := ioutil.ReadDir(cfg.pkgFull)
@@ -191,6 +439,7 @@ func sanityCheck(cfg config) {
 			}
 		}
 	}
+	return elapsed, flaky
 }
 
 func generateCoverprofile(pkg string) []*cover.Profile {
@@ -199,7 +448,7 @@ func generateCoverprofile(pkg string) []*cover.Profile {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	cmd := exec.Command("go", "test", "-short", "-coverprofile", f.Name(), pkg)
+	cmd := exec.Command("go", testArgs(0, "-coverprofile", f.Name(), pkg)...)
 	if err := cmd.Run(); err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
@@ -225,10 +474,21 @@ func generateCoverprofile(pkg string) []*cover.Profile {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "minimize" {
+		runMinimize(os.Args[2:])
+		return
+	}
+
 	start := time.Now()
 	cfg := getRunConfig()
 
-	sanityCheck(cfg)
+	elapsed, flaky := sanityCheck(cfg)
+	if cfg.timeout == 0 {
+		cfg.timeout = 10 * elapsed
+		if cfg.timeout < minTimeout {
+			cfg.timeout = minTimeout
+		}
+	}
 
 	coverprofiles := generateCoverprofile(cfg.pkg)
 
@@ -240,8 +500,29 @@ func main() {
 	}
 	defer os.RemoveAll(tmpDir)
 
+	// weak mutation analysis, keyed by mutantKey.
+	var weak map[string]weakStatus
+	if *weakFlag || *weakOnlyFlag {
+		weak = runWeak(cfg, coverprofiles, tmpDir)
+		if *weakOnlyFlag {
+			return
+		}
+	}
+
+	selected, population := selectMutants(cfg, coverprofiles)
+
+	var targets []string
+	if *fuzzTimeFlag > 0 {
+		lp, err := loadPackage(cfg.pkgFull)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		targets = fuzzTargets(lp)
+	}
+
 	results := make(chan result)
-	sigs := make(chan os.Signal)
+	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigs
@@ -249,7 +530,7 @@ func main() {
 	}()
 
 	// build the "list" of mutators.
-	c := make(chan godzilla.Mutator, len(cfg.mutations))
+	c := make(chan string, len(cfg.mutations))
 	for _, mutator := range cfg.mutations {
 		c <- mutator
 	}
@@ -257,17 +538,30 @@ func main() {
 
 	// launch all mutator worker.
 	var wg sync.WaitGroup
+	hashes := &hashSet{seen: make(map[[sha256.Size]byte]bool)}
 	for n := 0; n < runtime.NumCPU(); n++ {
+		// every worker has its own GOPATH so the mutant is built with the
+		// import path of the original package, this makes the binaries of
+		// different workers comparable and lets external test packages
+		// import the mutant.
 		workdir := filepath.Join(tmpDir, "godzilla"+strconv.Itoa(n))
-		if err := os.Mkdir(workdir, 0755); err != nil {
+		mutantDir := filepath.Join(workdir, "src", cfg.pkg)
+		if err := os.MkdirAll(mutantDir, 0755); err != nil {
 			fmt.Fprintf(os.Stderr, err.Error())
 			os.Exit(1)
 		}
 		w := worker{
-			mutantDir:     workdir,
+			mutantDir:     mutantDir,
 			originalDir:   cfg.pkgFull,
 			results:       results,
 			coverprofiles: coverprofiles,
+			timeout:       cfg.timeout,
+			env:           append(os.Environ(), "GOPATH="+workdir+string(filepath.ListSeparator)+cfg.gopath),
+			binary:        filepath.Join(workdir, "mutant.test"),
+			hashes:        hashes,
+			weak:          weak,
+			selected:      selected,
+			fuzzTargets:   targets,
 		}
 
 		wg.Add(1)
@@ -289,15 +583,119 @@ func main() {
 		res.alive += r.alive
 		res.total += r.total
 		res.skipped += r.skipped
+		res.timedout += r.timedout
+		res.equivalent += r.equivalent
+		res.duplicate += r.duplicate
+		res.filtered += r.filtered
+		res.gaps += r.gaps
+		res.flaky += r.flaky
+		res.fuzzed += r.fuzzed
+		res.mutants = append(res.mutants, r.mutants...)
+	}
+
+	fmt.Printf("score: %.1f%% (%d killed, %d alive, %d total, %d skipped, %d timed out, %d equivalent, %d duplicate, %d filtered) in %s\n", percent(res.total-res.alive, res.total), res.total-res.alive, res.alive, res.total, res.skipped, res.timedout, res.equivalent, res.duplicate, res.filtered, time.Since(start).String())
+	var est *estimate
+	if selected != nil && res.total == 0 {
+		fmt.Printf("no score estimated, none of the %d mutants sampled could be tested\n", len(selected))
+	} else if selected != nil {
+		e := estimateScore(res.total-res.alive, res.total, len(selected), population)
+		est = &e
+		fmt.Printf("estimated score: %.1f%% (%.1f%% - %.1f%% with 95%% confidence, %d of %d mutants sampled)\n", e.Score, e.Low, e.High, e.Sampled, e.Population)
+	}
+	if *fuzzTimeFlag > 0 {
+		fmt.Printf("fuzzing: %d mutants surviving the tests are killed by fuzzing\n", res.fuzzed)
+	}
+	if *confirmFlag > 0 {
+		fmt.Printf("flaky mutants: %d killed mutants passed the tests when re-run\n", res.flaky)
+	}
+	if weak != nil {
+		fmt.Printf("assertion gaps: %d alive mutants are infected by the tests\n", res.gaps)
 	}
 
-	fmt.Printf("score: %.1f%% (%d killed, %d alive, %d total, %d skipped) in %s\n", float64(res.total-res.alive)/float64(res.total)*100, res.total-res.alive, res.alive, res.total, res.skipped, time.Since(start).String())
+	var homs []homReport
+	if *homFlag > 0 {
+		statuses := make(map[string]string)
+		for _, m := range res.mutants {
+			statuses[m.ID] = m.Status
+		}
+		homs = runHigherOrder(cfg, coverprofiles, tmpDir, hashes, statuses)
+	}
+
+	if *reportFlag != "" {
+		// when the tests stop at the first failure, the kill sets depend on
+		// the order of the tests and tell nothing about subsumption.
+		var minimalScore *float64
+		if *fullMatrixFlag {
+			killed, alive, redundant := subsume(res.mutants)
+			score := percent(killed, killed+alive)
+			minimalScore = &score
+			fmt.Printf("minimal mutants: %.1f%% (%d killed, %d alive, %d redundant)\n", score, killed, alive, redundant)
+		}
+
+		tests, err := listTests(cfg.pkg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing the tests: %s\n", err.Error())
+		}
+		r := report{
+			Package:      cfg.pkg,
+			FullMatrix:   *fullMatrixFlag,
+			Score:        percent(res.total-res.alive, res.total),
+			MinimalScore: minimalScore,
+			Mutants:      res.mutants,
+			HigherOrder:  homs,
+			Estimate:     est,
+			FlakyTests:   flaky,
+		}
+		if err := writeReport(*reportFlag, r, tests); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing the report: %s\n", err.Error())
+			os.Exit(1)
+		}
+	}
 }
 
 // result is the data passed to the aggregator to sum the total number of mutant
 // executed and killed for a particular mutation.
+// Mutants whose tests timed out are counted as killed. Equivalent, duplicate
+// and filtered mutants are not tested and not counted in the total.
 type result struct {
-	alive, total, skipped int
+	alive, total, skipped, timedout int
+	equivalent, duplicate, filtered int
+	// alive mutants infected in weak mode.
+	gaps int
+	// killed mutants passing the tests when re-run, not counted in the
+	// total.
+	flaky int
+	// alive mutants killed by fuzzing, counted as killed.
+	fuzzed int
+	// the rows of the kill matrix, only collected with -report.
+	mutants []mutantReport
+}
+
+// hashSet is the set of the hashes of the mutant test binaries, shared by all
+// workers.
+type hashSet struct {
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]bool
+}
+
+// add adds h to the set and returns true if it was already there.
+func (s *hashSet) add(h [sha256.Size]byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[h] {
+		return true
+	}
+	s.seen[h] = true
+	return false
+}
+
+// hashFile returns the sha256 of the file at path.
+func hashFile(path string) ([sha256.Size]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(b), nil
 }
 
 // worker is a type that works on a specific mutant folder and pulls mutators
@@ -312,6 +710,22 @@ type worker struct {
 	results chan result
 
 	coverprofiles []*cover.Profile
+
+	// the time after which the tests of a mutant are stopped.
+	timeout time.Duration
+
+	// the environment of the go commands, it sets the GOPATH of the worker.
+	env []string
+	// the path of the compiled test binary.
+	binary string
+	hashes *hashSet
+
+	// the weak mode status of the expression mutants, nil without -weak.
+	weak map[string]weakStatus
+	// the IDs of the mutants to test, nil to test them all.
+	selected map[string]bool
+	// the fuzz targets run against the alive mutants, nil without -fuzz-time.
+	fuzzTargets []string
 }
 
 // visitor is a struct that runs a particular mutation case on the ast.Package.
@@ -321,12 +735,21 @@ type visitor struct {
 	tester    tester
 }
 
-// Mutate starts mutating the source, it gets the mutators from the given
-// channel.
-func (w worker) Mutate(c chan godzilla.Mutator) {
+// loadedPackage is the parsed and type checked package to mutate.
+type loadedPackage struct {
+	fset *token.FileSet
+	// the package and, if any, its external test package.
+	pkgs  map[string]*ast.Package
+	pkg   *ast.Package
+	info  *types.Info
+	types *types.Package
+}
+
+// loadPackage parses and type checks the package in dir.
+func loadPackage(dir string) (*loadedPackage, error) {
 	// Parse the entire package
 	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, w.originalDir, nil, parser.ParseComments)
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
 	if err != nil {
 		// The code compiled, this should never happen
 		panic(err)
@@ -357,59 +780,81 @@ func (w worker) Mutate(c chan godzilla.Mutator) {
 	}
 
 	conf := types.Config{Importer: importer.Default()}
-	if _, err = conf.Check(pkg.Name, fset, files, info); err != nil {
-		fmt.Fprintln(os.Stderr, "Error determining ast types:", err.Error())
-		return
+	typesPkg, err := conf.Check(pkg.Name, fset, files, info)
+	if err != nil {
+		return nil, fmt.Errorf("Error determining ast types: %s", err.Error())
 	}
 
-	// write all files to the mutant directory
-	for _, pkg := range pkgs {
+	return &loadedPackage{
+		fset:  fset,
+		pkgs:  pkgs,
+		pkg:   pkg,
+		info:  info,
+		types: typesPkg,
+	}, nil
+}
+
+// writeFiles writes all the files of the package, and of its external test
+// package, to dir.
+func (p *loadedPackage) writeFiles(dir string) error {
+	for _, pkg := range p.pkgs {
 		for fullFileName, astFile := range pkg.Files {
 			baseName := filepath.Base(fullFileName)
-			file, err := os.OpenFile(filepath.Join(w.mutantDir, baseName), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0700)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error opening %s: %s\n", baseName, err.Error())
-				return
+			var b bytes.Buffer
+			if err := format.Node(&b, p.fset, astFile); err != nil {
+				return fmt.Errorf("Error printing %s: %s", baseName, err.Error())
 			}
-			if err = format.Node(file, fset, astFile); err != nil {
-				fmt.Fprintf(os.Stderr, "Error printing %s: %s\n", baseName, err.Error())
-				return
+			if err := ioutil.WriteFile(filepath.Join(dir, baseName), b.Bytes(), 0700); err != nil {
+				return fmt.Errorf("Error writing %s: %s", baseName, err.Error())
 			}
 		}
 	}
+	return nil
+}
+
+// coveredBlocks returns the coverage blocks of the file name.
+func coveredBlocks(coverprofiles []*cover.Profile, name string) []cover.ProfileBlock {
+	for _, p := range coverprofiles {
+		if strings.HasSuffix(name, p.FileName) {
+			return p.Blocks
+		}
+	}
+	return nil
+}
+
+// Mutate starts mutating the source, it gets the mutators from the given
+// channel.
+func (w worker) Mutate(c chan string) {
+	lp, err := loadPackage(w.originalDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
 
-	for m := range c {
-		for name, file := range pkg.Files {
+	// write all files to the mutant directory
+	if err := lp.writeFiles(w.mutantDir); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+	if err := copyFuzzCorpus(w.originalDir, w.mutantDir); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	original := w.originalHash()
+	for mutator := range c {
+		for name, file := range lp.pkg.Files {
 			// don't mutate test files.
 			if strings.HasSuffix(name, "_test.go") {
 				continue
 			}
 
-			// find the block we actually care about.
-			var blocks []cover.ProfileBlock
-			for _, p := range w.coverprofiles {
-				if !strings.HasSuffix(name, p.FileName) {
-					continue
-				}
-				blocks = p.Blocks
-				break
-			}
-
 			v := &visitor{
-				mutator: m,
-				parseInfo: godzilla.ParseInfo{
-					FileSet:       fset,
-					CoveredBlocks: blocks,
-					TypesInfo:     info,
-				},
-				tester: tester{
-					mutantDir:   w.mutantDir,
-					originalDir: w.originalDir,
-					astFile:     file,
-					astFileName: name,
-					fset:        fset,
-				},
+				mutator:   godzilla.Mutators[mutator].M,
+				parseInfo: w.parseInfo(lp, name, file),
+				tester:    w.tester(lp, name, file, original),
 			}
+			v.tester.mutator = mutator
 
 			ast.Walk(v, file)
 			w.results <- v.tester.result
@@ -417,6 +862,55 @@ func (w worker) Mutate(c chan godzilla.Mutator) {
 	}
 }
 
+// originalHash returns the hash of the test binary of the unmodified package,
+// mutants compiling to it are equivalent. It is zero if TCE is disabled.
+func (w worker) originalHash() [sha256.Size]byte {
+	var original [sha256.Size]byte
+	if *tceFlag {
+		if _, err := compileTest(w.mutantDir, w.binary, w.env); err == nil {
+			original, err = hashFile(w.binary)
+			if err != nil {
+				// no test files.
+				original = [sha256.Size]byte{}
+			}
+		}
+	}
+	return original
+}
+
+// parseInfo returns the information given to the mutators of the file name.
+func (w worker) parseInfo(lp *loadedPackage, name string, file *ast.File) godzilla.ParseInfo {
+	return godzilla.ParseInfo{
+		FileSet:       lp.fset,
+		CoveredBlocks: coveredBlocks(w.coverprofiles, name),
+		TypesInfo:     lp.info,
+		Package:       lp.types,
+		File:          file,
+		Uses:          godzilla.LocalUses(lp.info, file),
+	}
+}
+
+// tester returns the tester of the mutants of the file name.
+func (w worker) tester(lp *loadedPackage, name string, file *ast.File, original [sha256.Size]byte) tester {
+	return tester{
+		mutantDir:   w.mutantDir,
+		originalDir: w.originalDir,
+		astFile:     file,
+		astFileName: name,
+		fset:        lp.fset,
+		importNames: importNames(file, lp.info),
+		timeout:     w.timeout,
+		env:         w.env,
+		binary:      w.binary,
+		original:    original,
+		hashes:      w.hashes,
+		weak:        w.weak,
+		selected:    w.selected,
+		fuzzTargets: w.fuzzTargets,
+		numbers:     make(map[token.Pos]int),
+	}
+}
+
 type tester struct {
 	// the directory that this mutant should test into.
 	mutantDir string
@@ -430,66 +924,331 @@ type tester struct {
 
 	fset *token.FileSet
 
+	// the package names of the file imports, indexed by import path.
+	importNames map[string]string
+
+	timeout time.Duration
+
+	env    []string
+	binary string
+	// the hash of the original test binary, zero if TCE is disabled.
+	original [sha256.Size]byte
+	hashes   *hashSet
+
+	weak map[string]weakStatus
+	// the key of the expression mutant being tested, if known.
+	mutantKey string
+
+	// the name of the mutator, the position of the node it mutates and the
+	// number of mutants it generated for each position, they identify the
+	// mutant in the report.
+	mutator string
+	pos     token.Pos
+	numbers map[token.Pos]int
+	// the ID of the last mutant tested.
+	id string
+
+	// the status of the last mutant tested, empty if it didn't compile.
+	status string
+	// don't print the alive mutants.
+	quiet bool
+	// the mutants are higher-order mutants, they are neither fuzzed nor
+	// added to the result.
+	higherOrder bool
+	// the IDs of the mutants to test, nil to test them all.
+	selected map[string]bool
+	// the fuzz targets run against the alive mutants, the target killing the
+	// mutant and its input.
+	fuzzTargets []string
+	fuzzTarget  string
+	fuzzInput   string
+
 	result result
 }
 
+// importNames returns the names under which the packages imported by file are
+// referred to, indexed by import path.
+func importNames(file *ast.File, info *types.Info) map[string]string {
+	names := make(map[string]string)
+	for _, spec := range file.Imports {
+		obj, ok := info.Implicits[spec]
+		if spec.Name != nil {
+			obj, ok = info.Defs[spec.Name]
+		}
+		if !ok || obj == nil {
+			continue
+		}
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		names[path] = obj.Name()
+	}
+	return names
+}
+
+// removeUnusedImports removes the imports that are not used anymore by the
+// mutant source. Mutators that remove code might remove the only reference to
+// a package and that would not compile.
+func (t *tester) removeUnusedImports(src []byte) []byte {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, t.astFileName, src, parser.ParseComments)
+	if err != nil {
+		return src
+	}
+
+	// package references are the selector expressions that the parser
+	// couldn't resolve to a local object.
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	removed := false
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name, ok := t.importNames[path]
+		if !ok || name == "_" || name == "." || used[name] {
+			continue
+		}
+		var ident string
+		if spec.Name != nil {
+			ident = spec.Name.Name
+		}
+		removed = astutil.DeleteNamedImport(fset, file, ident, path) || removed
+	}
+	if !removed {
+		return src
+	}
+
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, file); err != nil {
+		return src
+	}
+	return b.Bytes()
+}
+
 // Test take the current ast.Package, rewrites the source and test it.
 func (t *tester) Test() {
-	// rewrite file in the mutant dir
 	baseName := filepath.Base(t.astFileName)
-	file, err := os.OpenFile(filepath.Join(t.mutantDir, baseName), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0700)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening %s: %s\n", baseName, err.Error())
+	t.id = mutantID(t.mutator, baseName, t.fset.Position(t.pos), t.numbers[t.pos])
+	t.numbers[t.pos]++
+	if t.selected != nil && !t.selected[t.id] {
+		t.status = ""
 		return
 	}
-	if err = format.Node(file, t.fset, t.astFile); err != nil {
+	t.test()
+}
+
+// test rewrites the file of the mutant in the mutant dir and tests it, leaving
+// its status in t.status.
+func (t *tester) test() {
+	t.status = ""
+	t.fuzzTarget, t.fuzzInput = "", ""
+
+	// rewrite file in the mutant dir
+	baseName := filepath.Base(t.astFileName)
+	var b bytes.Buffer
+	if err := format.Node(&b, t.fset, t.astFile); err != nil {
 		fmt.Fprintf(os.Stderr, "Error printing %s: %s\n", baseName, err.Error())
 		return
 	}
+	src := t.removeUnusedImports(b.Bytes())
+	if err := ioutil.WriteFile(filepath.Join(t.mutantDir, baseName), src, 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %s\n", baseName, err.Error())
+		return
+	}
 
 	// Verify that the mutant we generated actually compiles
 	cmd := exec.Command("go", "build")
 	cmd.Dir = t.mutantDir
-	if err := cmd.Run(); err != nil {
-		t.result.skipped++
-		// that message is not expected to appear. That implies one of the
-		// mutator build a code tree that doesn't compile. Ideally we could
-		// report the code generated and why it didn't compile.
-		if *diffonlyinvalid {
-			t.PrintDiff(baseName)
+	cmd.Env = t.env
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.invalid(baseName, out)
+		return
+	}
+
+	// execute the tests in that folder, the GOPATH of the worker comes first
+	// so external test packages import the mutant.
+	args := mutantTestArgs(t.timeout)
+	if t.original != ([sha256.Size]byte{}) {
+		// trivial compiler equivalence, mutants compiling to the same binary
+		// as the original or as another mutant don't need to be tested.
+		// Mutants whose tests don't compile are invalid like those whose
+		// package doesn't.
+		if out, err := compileTest(t.mutantDir, t.binary, t.env); err != nil {
+			t.invalid(baseName, out)
 			return
 		}
-		fmt.Println("invalid build")
+		h, err := hashFile(t.binary)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %s\n", t.binary, err.Error())
+			return
+		}
+		if h == t.original {
+			t.result.equivalent++
+			t.record(baseName, src, "equivalent", nil, nil)
+			return
+		}
+		if t.hashes.add(h) {
+			t.result.duplicate++
+			t.record(baseName, src, "duplicate", nil, nil)
+			return
+		}
+		args = mutantBinaryArgs(t.binary, t.timeout)
+	}
+	run := func() (int, []byte) {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = t.mutantDir
+		cmd.Env = t.env
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		return getExitCode(cmd.Run()), out.Bytes()
+	}
+	exitCode, out := run()
+	events := parseTestEvents(out)
+	failed, passed := events.failed, events.passed
+	if events.buildFailed {
+		// eg. go vet rejected the mutant.
+		t.invalid(baseName, events.buildOutput)
+		return
+	}
+	if exitCode != 0 {
+		// the tests failed, the mutant is killed unless they pass when re-run.
+		// Mutants that hang (eg. a select without default) are stopped by the
+		// go test timeout, they aren't re-run since every run would last
+		// until the timeout.
+		for i := 0; i < *confirmFlag && !events.timedOut; i++ {
+			if code, _ := run(); code == 0 {
+				t.result.flaky++
+				t.record(baseName, src, "flaky", failed, passed)
+				return
+			}
+		}
+		t.result.total++
+		status := "killed"
+		if events.timedOut {
+			t.result.timedout++
+			status = "timedout"
+		}
+		t.record(baseName, src, status, failed, passed)
 		return
 	}
-
-	// execute `go test` in that folder, the GOPATH can stay the same as the
-	// callers.
-	// BUG(hydroflame): when the test package is called *_test this will fail to
-	// import the actual mutant, make the GOPATH var of the cmd be
-	// `GOPATH=.../mutantDir:ActualGOPATH`
-	cmd = exec.Command("go", "test", "-short")
-	cmd.Dir = t.mutantDir
 	t.result.total++
-	if getExitCode(cmd.Run()) != 0 {
-		// the tests failed, the mutant is killed.
+	if target, input := t.fuzz(baseName, src); target != "" {
+		t.result.fuzzed++
+		t.fuzzTarget, t.fuzzInput = target, input
+		// the fuzz target isn't a test killing the mutant, it is left out
+		// of the kill matrix.
+		t.record(baseName, src, "fuzzed", nil, passed)
+		if !*diffonlyinvalid && !t.quiet {
+			fmt.Printf("killed by fuzzing with %s, the input is saved to %s\n", target, input)
+			t.PrintDiff(baseName)
+		}
 		return
 	}
 	t.result.alive++
+	t.record(baseName, src, "alive", nil, passed)
 
-	if !*diffonlyinvalid {
+	if t.weak[t.mutantKey].infected {
+		t.result.gaps++
+		if !*diffonlyinvalid {
+			fmt.Println("assertion gap: this mutant is infected by the tests but not killed")
+		}
+	}
+
+	if !*diffonlyinvalid && !t.quiet {
 		t.PrintDiff(baseName)
 	}
 
 }
 
-func (t *tester) PrintDiff(baseName string) {
-	// Print the diff of the old and new file to the user.
+// invalid counts a mutant that doesn't compile, out is the compiler output.
+func (t *tester) invalid(baseName string, out []byte) {
+	t.result.skipped++
+	// that message is not expected to appear. That implies one of the
+	// mutator build a code tree that doesn't compile.
+	if *diffonlyinvalid {
+		t.PrintDiff(baseName)
+	} else {
+		fmt.Println("invalid build")
+	}
+	os.Stdout.Write(out)
+}
+
+// TestMutant tests the mutant like Test, knowing the mutated expression lets
+// the alive mutants be matched with the weak mode. This makes *tester
+// implement the godzilla.MutantTester interface.
+func (t *tester) TestMutant(orig, mutant ast.Node) {
+	if t.weak != nil {
+		t.mutantKey, _, _, _ = mutantKey(t.fset, t.astFileName, orig, mutant)
+	}
+	t.Test()
+	t.mutantKey = ""
+}
+
+// Discard counts the mutants discarded by the filters of godzilla. This makes
+// *tester implement the godzilla.Discarder interface.
+func (t *tester) Discard(filter string, pos token.Position, reason string) {
+	// discarded mutants keep their number, the IDs don't depend on the
+	// filters.
+	t.numbers[t.pos]++
+	t.result.filtered++
+	if *filteredFlag {
+		fmt.Printf("%s: discarded by %s: %s\n", pos, filter, reason)
+	}
+}
+
+// record adds the mutant to the kill matrix of the report, failed and passed
+// are the tests that failed and passed against the mutant.
+func (t *tester) record(baseName string, src []byte, status string, failed, passed []string) {
+	t.status = status
+	// the higher-order mutants need the status of their mutants, they are
+	// reported by homTester.
+	if t.higherOrder || (*reportFlag == "" && *homFlag == 0) {
+		return
+	}
+	orig, err := ioutil.ReadFile(filepath.Join(t.originalDir, baseName))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %s\n", baseName, err.Error())
+		return
+	}
+	t.result.mutants = append(t.result.mutants, mutantReport{
+		ID:        t.id,
+		Mutator:   t.mutator,
+		File:      baseName,
+		Line:      firstChangedLine(orig, src),
+		Status:    status,
+		KilledBy:  failed,
+		Passed:    passed,
+		Diff:      string(t.diff(baseName)),
+		FuzzedBy:  t.fuzzTarget,
+		FuzzInput: t.fuzzInput,
+	})
+}
+
+// diff returns the diff of the old and new file.
+func (t *tester) diff(baseName string) []byte {
 	cmd := exec.Command("diff", "-u",
 		filepath.Join(t.originalDir, baseName),
 		filepath.Join(t.mutantDir, baseName))
-	cmd.Stdout = os.Stdout
-	cmd.Run()
+	// diff exits with 1 when the files differ.
+	out, _ := cmd.Output()
+	return out
+}
+
+func (t *tester) PrintDiff(baseName string) {
+	// Print the diff of the old and new file to the user.
+	os.Stdout.Write(t.diff(baseName))
 }
 
 // getExitCode returns the exit code of an error returned by os/exec.Cmd.Run()
@@ -511,6 +1270,7 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 		return v
 	}
 
+	v.tester.pos = node.Pos()
 	v.mutator(v.parseInfo, node, &v.tester)
 	return v
 }
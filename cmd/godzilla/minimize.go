@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// minimization is a subset of the tests killing the same mutants as the whole
+// suite.
+type minimization struct {
+	// the selected top level tests, in the order they were picked, and the
+	// number of mutants each adds.
+	tests []string
+	gains []int
+	// the tests left out of the selection, they kill no mutant that isn't
+	// killed by the selected tests.
+	redundant []string
+	// the number of top level tests.
+	total int
+	// the killed mutants and those that no test is known to kill, eg.
+	// because the tests didn't compile.
+	killed, unattributed int
+}
+
+// minimize computes a minimal subset of the tests of r keeping its mutation
+// score. Finding the smallest one is the set cover problem, the tests are
+// picked greedily: the test killing the most mutants not killed yet first.
+// Subtests are counted as their top level test, a failing subtest fails its
+// parent and -run selects subtests along with their parent anyway.
+func minimize(r *report) minimization {
+	var m minimization
+	kills := make(map[string]map[string]bool)
+	for _, t := range r.Tests {
+		kills[topLevel(t.Name)] = make(map[string]bool)
+	}
+	killed := make(map[string]bool)
+	for _, mutant := range r.Mutants {
+		if mutant.Status != "killed" && mutant.Status != "timedout" {
+			continue
+		}
+		m.killed++
+		if len(mutant.KilledBy) == 0 {
+			m.unattributed++
+			continue
+		}
+		for _, test := range mutant.KilledBy {
+			test = topLevel(test)
+			if kills[test] == nil {
+				kills[test] = make(map[string]bool)
+			}
+			kills[test][mutant.ID] = true
+		}
+		killed[mutant.ID] = true
+	}
+
+	var tests []string
+	for test := range kills {
+		tests = append(tests, test)
+	}
+	sort.Strings(tests)
+	m.total = len(tests)
+
+	covered := make(map[string]bool)
+	selected := make(map[string]bool)
+	for len(covered) < len(killed) {
+		best, gain := "", 0
+		for _, test := range tests {
+			n := 0
+			for id := range kills[test] {
+				if !covered[id] {
+					n++
+				}
+			}
+			if n > gain {
+				best, gain = test, n
+			}
+		}
+		for id := range kills[best] {
+			covered[id] = true
+		}
+		selected[best] = true
+		m.tests = append(m.tests, best)
+		m.gains = append(m.gains, gain)
+	}
+
+	for _, test := range tests {
+		if !selected[test] {
+			m.redundant = append(m.redundant, test)
+		}
+	}
+	return m
+}
+
+// topLevel returns the top level test of the test or subtest.
+func topLevel(test string) string {
+	return strings.SplitN(test, "/", 2)[0]
+}
+
+// readReport reads the JSON report written with -report.
+func readReport(name string) (*report, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var r report
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %s", name, err.Error())
+	}
+	return &r, nil
+}
+
+// runMinimize is the minimize command, it prints the minimal subset of the
+// tests of the report given in args.
+func runMinimize(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: godzilla minimize report.json")
+		os.Exit(1)
+	}
+	r, err := readReport(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if !r.FullMatrix {
+		fmt.Fprintln(os.Stderr, "warning: the report is not a full kill matrix, the tests stopped at the first failure so the minimal set may be larger than needed and tests may be wrongly reported redundant. Run godzilla with -fullmatrix.")
+	}
+
+	m := minimize(r)
+	fmt.Printf("%d of %d tests kill the %d mutants killed by the suite:\n", len(m.tests), m.total, m.killed-m.unattributed)
+	for i, test := range m.tests {
+		fmt.Printf("	%s (+%d)\n", test, m.gains[i])
+	}
+	if m.unattributed > 0 {
+		fmt.Printf("%d killed mutants are not killed by a particular test\n", m.unattributed)
+	}
+	if len(m.tests) > 0 {
+		fmt.Printf("run them with: -run '^(%s)$'\n", strings.Join(m.tests, "|"))
+	}
+	// of two tests killing the same mutants only one is redundant, the
+	// redundant tests can't be removed without keeping the tests above.
+	fmt.Printf("%d redundant tests kill no mutant that isn't killed by the tests above, they can be removed as long as the tests above are kept:\n", len(m.redundant))
+	for _, test := range m.redundant {
+		fmt.Printf("	%s\n", test)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMinimize(t *testing.T) {
+	killed := func(id string, tests ...string) mutantReport {
+		return mutantReport{ID: id, Status: "killed", KilledBy: tests}
+	}
+	for _, tc := range []struct {
+		name      string
+		r         report
+		tests     []string
+		gains     []int
+		redundant []string
+		total     int
+		killed    int
+	}{
+		{
+			name: "greedy",
+			r: report{
+				Tests: []testReport{{Name: "TestA"}, {Name: "TestB"}, {Name: "TestC"}, {Name: "TestD"}},
+				Mutants: []mutantReport{
+					killed("m1", "TestA", "TestB"),
+					killed("m2", "TestB"),
+					killed("m3", "TestB", "TestC"),
+					killed("m4", "TestC"),
+					{ID: "m5", Status: "alive"},
+				},
+			},
+			tests:     []string{"TestB", "TestC"},
+			gains:     []int{3, 1},
+			redundant: []string{"TestA", "TestD"},
+			total:     4,
+			killed:    4,
+		},
+		{
+			name: "subtests",
+			r: report{
+				Tests: []testReport{{Name: "TestA"}, {Name: "TestA/sub"}, {Name: "TestB"}},
+				Mutants: []mutantReport{
+					killed("m1", "TestA", "TestA/sub"),
+					killed("m2", "TestB"),
+				},
+			},
+			tests:  []string{"TestA", "TestB"},
+			gains:  []int{1, 1},
+			total:  2,
+			killed: 2,
+		},
+		{
+			name: "same kills",
+			r: report{
+				Tests: []testReport{{Name: "TestA"}, {Name: "TestB"}},
+				Mutants: []mutantReport{
+					killed("m1", "TestA", "TestB"),
+					killed("m2", "TestA", "TestB"),
+				},
+			},
+			tests:     []string{"TestA"},
+			gains:     []int{2},
+			redundant: []string{"TestB"},
+			total:     2,
+			killed:    2,
+		},
+		{
+			name: "unattributed",
+			r: report{
+				Tests: []testReport{{Name: "TestA"}},
+				Mutants: []mutantReport{
+					killed("m1"),
+					{ID: "m2", Status: "timedout", KilledBy: []string{"TestA"}},
+				},
+			},
+			tests:  []string{"TestA"},
+			gains:  []int{1},
+			total:  1,
+			killed: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := minimize(&tc.r)
+			if !reflect.DeepEqual(m.tests, tc.tests) || !reflect.DeepEqual(m.gains, tc.gains) {
+				t.Errorf("tests = %v %v, want %v %v", m.tests, m.gains, tc.tests, tc.gains)
+			}
+			if !reflect.DeepEqual(m.redundant, tc.redundant) {
+				t.Errorf("redundant = %v, want %v", m.redundant, tc.redundant)
+			}
+			if m.total != tc.total || m.killed != tc.killed {
+				t.Errorf("total, killed = %d, %d, want %d, %d", m.total, m.killed, tc.total, tc.killed)
+			}
+		})
+	}
+}
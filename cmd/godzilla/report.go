@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// report is the JSON report written with -report.
+type report struct {
+	Package string `json:"package"`
+	// FullMatrix is true if all the tests were run against every mutant,
+	// otherwise the tests stopped at the first failure.
+	FullMatrix bool    `json:"fullMatrix"`
+	Score      float64 `json:"score"`
+	// MinimalScore is the score of the minimal mutants, the mutants that are
+	// not redundant with another. It is only computed from a full matrix.
+	MinimalScore *float64 `json:"minimalScore,omitempty"`
+	// Tests lists the tests of the package with the number of mutants they
+	// killed.
+	Tests   []testReport   `json:"tests"`
+	Mutants []mutantReport `json:"mutants"`
+	// Estimate is the score estimated from the sample of the mutants tested
+	// with -sample or -max-mutants.
+	Estimate *estimate `json:"estimate,omitempty"`
+	// FlakyTests are the tests found flaky with -flakyruns, they were skipped.
+	FlakyTests []string `json:"flakyTests,omitempty"`
+	// HigherOrder are the higher-order mutants tested with -hom.
+	HigherOrder []homReport `json:"higherOrder,omitempty"`
+}
+
+// testReport is a test of the package.
+type testReport struct {
+	Name  string `json:"name"`
+	Kills int    `json:"kills"`
+}
+
+// mutantReport is a row of the kill matrix.
+type mutantReport struct {
+	// ID identifies the mutant, it is stable as long as the source doesn't
+	// change.
+	ID      string `json:"id"`
+	Mutator string `json:"mutator"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	// Status is one of killed, alive, timedout, equivalent, duplicate,
+	// fuzzed if it is killed by fuzzing only or flaky if the tests killing it
+	// passed when re-run.
+	Status string `json:"status"`
+	// KilledBy are the tests that failed and Passed the tests that passed.
+	KilledBy []string `json:"killedBy,omitempty"`
+	Passed   []string `json:"passed,omitempty"`
+	Diff     string   `json:"diff,omitempty"`
+	// FuzzedBy is the fuzz target killing a fuzzed mutant and FuzzInput the
+	// input killing it saved in the package.
+	FuzzedBy  string `json:"fuzzedBy,omitempty"`
+	FuzzInput string `json:"fuzzInput,omitempty"`
+	// Minimal is true if the mutant is in the minimal mutant set, otherwise
+	// RedundantWith is the minimal mutant making it redundant. Both are only
+	// set in a full matrix.
+	Minimal       bool   `json:"minimal,omitempty"`
+	RedundantWith string `json:"redundantWith,omitempty"`
+}
+
+// homReport is a higher-order mutant, combining the mutants First and Second.
+type homReport struct {
+	First  string `json:"first"`
+	Second string `json:"second"`
+	Status string `json:"status"`
+	// Masking is true if the mutant is alive while both its mutants are
+	// killed, one mutation hides the other from the tests.
+	Masking bool   `json:"masking"`
+	Diff    string `json:"diff,omitempty"`
+}
+
+// mutantID returns the ID of the n-th mutant of mutator for the nodes at pos in
+// the file. Unlike a count of the mutants of the file, the position doesn't
+// depend on the mutators and filters run.
+func mutantID(mutator, file string, pos token.Position, n int) string {
+	return fmt.Sprintf("%s:%s:%d:%d:%d", mutator, file, pos.Line, pos.Column, n)
+}
+
+// testEvent is an event of the output of `go test -json`.
+type testEvent struct {
+	Action      string
+	Test        string
+	Output      string
+	FailedBuild string
+}
+
+// testRun is the outcome of a run of the tests.
+type testRun struct {
+	// the tests that failed and passed.
+	failed, passed []string
+	// the tests didn't build, eg. because go vet failed, and the output of
+	// the build.
+	buildFailed bool
+	buildOutput []byte
+	// the tests were stopped by the -timeout of go test.
+	timedOut bool
+}
+
+// timeoutPanic starts the output of the test binary when it times out.
+const timeoutPanic = "panic: test timed out after "
+
+// parseTestEvents parses the output of `go test -json`. The lines that are not
+// events, printed by older go versions, are part of the build output.
+func parseTestEvents(out []byte) testRun {
+	var run testRun
+	// the tests printing the timeout panic, the timed out test is the one
+	// that doesn't pass: the panic stops it, while tests can print anything.
+	panicked := make(map[string]bool)
+	passed := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var e testEvent
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			run.buildOutput = append(append(run.buildOutput, scanner.Bytes()...), '\n')
+			continue
+		}
+		switch {
+		case e.Action == "build-output":
+			run.buildOutput = append(run.buildOutput, e.Output...)
+		case e.Action == "build-fail" || e.FailedBuild != "":
+			run.buildFailed = true
+		case e.Action == "output":
+			if strings.HasPrefix(e.Output, timeoutPanic) {
+				panicked[e.Test] = true
+			}
+		case e.Test == "":
+		case e.Action == "fail":
+			run.failed = append(run.failed, e.Test)
+		case e.Action == "pass":
+			run.passed = append(run.passed, e.Test)
+			passed[e.Test] = true
+		}
+	}
+	for test := range panicked {
+		if !passed[test] {
+			run.timedOut = true
+		}
+	}
+	return run
+}
+
+// listTests returns the names of the top level tests, examples and fuzz targets
+// of pkg, benchmarks are not run.
+func listTests(pkg string) ([]string, error) {
+	out, err := exec.Command("go", "test", "-list", ".", pkg).Output()
+	if err != nil {
+		return nil, err
+	}
+	var tests []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "Test") || strings.HasPrefix(line, "Example") || strings.HasPrefix(line, "Fuzz") {
+			tests = append(tests, line)
+		}
+	}
+	return tests, nil
+}
+
+// firstChangedLine returns the first line that differs between a and b.
+func firstChangedLine(a, b []byte) int {
+	al, bl := bytes.Split(a, []byte("\n")), bytes.Split(b, []byte("\n"))
+	for i := range al {
+		if i >= len(bl) || !bytes.Equal(al[i], bl[i]) {
+			return i + 1
+		}
+	}
+	return len(al)
+}
+
+// subsume computes the minimal mutant set of the mutants and returns the number
+// of killed and alive minimal mutants, and of redundant mutants.
+//
+// A killed mutant subsumes the mutants killed by all the tests killing it,
+// killing it guarantees killing them. Mutants killed by the same tests are
+// redundant with each other and the minimal set holds one mutant of each group
+// that isn't subsumed. The kill matrix tells nothing about the alive mutants,
+// they are all minimal. Equivalent, duplicate and mutants killed by no
+// particular test are left out.
+func subsume(mutants []mutantReport) (killed, alive, redundant int) {
+	sort.Slice(mutants, func(i, j int) bool { return mutants[i].ID < mutants[j].ID })
+
+	// the groups of killed mutants, indexed by their kill sets.
+	groups := make(map[string][]*mutantReport)
+	var keys []string
+	sets := make(map[string]map[string]bool)
+	for i := range mutants {
+		m := &mutants[i]
+		m.Minimal, m.RedundantWith = false, ""
+		if m.Status == "alive" {
+			m.Minimal = true
+			alive++
+			continue
+		}
+		if (m.Status != "killed" && m.Status != "timedout") || len(m.KilledBy) == 0 {
+			continue
+		}
+		tests := append([]string(nil), m.KilledBy...)
+		sort.Strings(tests)
+		key := strings.Join(tests, "\x00")
+		if sets[key] == nil {
+			sets[key] = make(map[string]bool)
+			for _, test := range tests {
+				sets[key][test] = true
+			}
+		}
+		if groups[key] == nil {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], m)
+	}
+
+	// subset returns true if the tests of a are a strict subset of those of b.
+	subset := func(a, b map[string]bool) bool {
+		if len(a) >= len(b) {
+			return false
+		}
+		for test := range a {
+			if !b[test] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, key := range keys {
+		// the representative of the group is its first mutant, unless the
+		// group is subsumed by a minimal group.
+		rep := groups[key][0]
+		for _, other := range keys {
+			if subset(sets[other], sets[key]) {
+				rep = nil
+				break
+			}
+		}
+		if rep == nil {
+			continue
+		}
+		killed++
+		rep.Minimal = true
+		for _, m := range groups[key][1:] {
+			m.RedundantWith = rep.ID
+			redundant++
+		}
+	}
+
+	// subsumed groups are redundant with a minimal group whose tests are a
+	// subset of theirs, there is always one since subset is a strict order.
+	for _, key := range keys {
+		if groups[key][0].Minimal {
+			continue
+		}
+		for _, other := range keys {
+			if groups[other][0].Minimal && subset(sets[other], sets[key]) {
+				for _, m := range groups[key] {
+					m.RedundantWith = groups[other][0].ID
+					redundant++
+				}
+				break
+			}
+		}
+	}
+	return killed, alive, redundant
+}
+
+// percent returns n out of total in percent, or 0 if total is 0 since the
+// report can't hold NaN.
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
+
+// writeReport writes the JSON report r to name, tests are the tests of the
+// package.
+func writeReport(name string, r report, tests []string) error {
+	kills := make(map[string]int)
+	for _, m := range r.Mutants {
+		for _, test := range m.KilledBy {
+			kills[test]++
+		}
+	}
+	// subtests are only known once they ran.
+	known := make(map[string]bool)
+	for _, test := range tests {
+		known[test] = true
+	}
+	for test := range kills {
+		if !known[test] {
+			tests = append(tests, test)
+			known[test] = true
+		}
+	}
+	sort.Strings(tests)
+
+	for _, test := range tests {
+		r.Tests = append(r.Tests, testReport{Name: test, Kills: kills[test]})
+	}
+	sort.Slice(r.Mutants, func(i, j int) bool {
+		a, b := r.Mutants[i], r.Mutants[j]
+		if a.Mutator != b.Mutator {
+			return a.Mutator < b.Mutator
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+
+	b, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, b, 0644)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubsume(t *testing.T) {
+	mutants := []mutantReport{
+		{ID: "a", Status: "killed", KilledBy: []string{"TestA"}},
+		{ID: "b", Status: "killed", KilledBy: []string{"TestA"}},
+		{ID: "c", Status: "killed", KilledBy: []string{"TestA", "TestB"}},
+		{ID: "d", Status: "timedout", KilledBy: []string{"TestB"}},
+		{ID: "e", Status: "killed", KilledBy: []string{"TestB", "TestC"}},
+		{ID: "f", Status: "alive", Line: 1},
+		{ID: "g", Status: "alive", Line: 1},
+		{ID: "h", Status: "killed"},
+		{ID: "i", Status: "equivalent"},
+	}
+	killed, alive, redundant := subsume(mutants)
+	if killed != 2 || alive != 2 || redundant != 3 {
+		t.Errorf("subsume = %d killed, %d alive, %d redundant, want 2, 2, 3", killed, alive, redundant)
+	}
+
+	want := map[string]string{
+		"a": "",
+		"b": "a",
+		// killed by TestA or TestB, a or d kill it.
+		"c": "a",
+		"d": "",
+		"e": "d",
+		"f": "",
+		"g": "",
+	}
+	for _, m := range mutants {
+		redundantWith, ok := want[m.ID]
+		if !ok {
+			if m.Minimal || m.RedundantWith != "" {
+				t.Errorf("%s: minimal = %v, redundant with %q, want left out", m.ID, m.Minimal, m.RedundantWith)
+			}
+			continue
+		}
+		if m.Minimal != (redundantWith == "") || m.RedundantWith != redundantWith {
+			t.Errorf("%s: minimal = %v, redundant with %q, want %q", m.ID, m.Minimal, m.RedundantWith, redundantWith)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	for _, tc := range []struct {
+		n, total int
+		want     float64
+	}{
+		{0, 0, 0},
+		{1, 4, 25},
+		{4, 4, 100},
+	} {
+		if got := percent(tc.n, tc.total); got != tc.want {
+			t.Errorf("percent(%d, %d) = %v, want %v", tc.n, tc.total, got, tc.want)
+		}
+	}
+}
+
+func TestParseTestEvents(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		out    string
+		failed []string
+		passed []string
+		build  bool
+		// the timeout panic is printed by the test binary.
+		timedOut bool
+	}{
+		{
+			name: "tests",
+			out: `{"Action":"run","Test":"TestA"}
+{"Action":"pass","Test":"TestA"}
+{"Action":"run","Test":"TestB"}
+{"Action":"fail","Test":"TestB/sub"}
+{"Action":"fail","Test":"TestB"}
+{"Action":"fail"}
+`,
+			failed: []string{"TestB/sub", "TestB"},
+			passed: []string{"TestA"},
+		},
+		{
+			name: "vet",
+			out: `{"ImportPath":"x [x.test]","Action":"build-output","Output":"# x\n"}
+{"ImportPath":"x [x.test]","Action":"build-fail"}
+{"Action":"start","Package":"x"}
+{"Action":"output","Package":"x","Output":"FAIL\tx [build failed]\n"}
+{"Action":"fail","Package":"x","FailedBuild":"x [x.test]"}
+`,
+			build: true,
+		},
+		{
+			name: "timeout",
+			out: `{"Action":"run","Test":"TestA"}
+{"Action":"output","Test":"TestA","Output":"panic: test timed out after 1s\n"}
+{"Action":"pass","Test":"TestA"}
+{"Action":"run","Test":"TestB"}
+{"Action":"output","Test":"TestB","Output":"panic: test timed out after 1s\n"}
+{"Action":"fail"}
+`,
+			passed:   []string{"TestA"},
+			timedOut: true,
+		},
+		{
+			name: "printed timeout",
+			out: `{"Action":"run","Test":"TestA"}
+{"Action":"output","Test":"TestA","Output":"panic: test timed out after 1s\n"}
+{"Action":"pass","Test":"TestA"}
+{"Action":"run","Test":"TestB"}
+{"Action":"fail","Test":"TestB"}
+{"Action":"fail"}
+`,
+			failed: []string{"TestB"},
+			passed: []string{"TestA"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			run := parseTestEvents([]byte(tc.out))
+			if !reflect.DeepEqual(run.failed, tc.failed) || !reflect.DeepEqual(run.passed, tc.passed) {
+				t.Errorf("failed %v and passed %v, want %v and %v", run.failed, run.passed, tc.failed, tc.passed)
+			}
+			if run.buildFailed != tc.build {
+				t.Errorf("build failed = %v, want %v", run.buildFailed, tc.build)
+			}
+			if run.timedOut != tc.timedOut {
+				t.Errorf("timed out = %v, want %v", run.timedOut, tc.timedOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hydroflame/godzilla"
+	"golang.org/x/tools/cover"
+)
+
+// estimate is the mutation score estimated from a sample of the mutants.
+type estimate struct {
+	Seed int64 `json:"seed"`
+	// Population is the number of mutants and Sampled the number of mutants
+	// tested.
+	Population int `json:"population"`
+	Sampled    int `json:"sampled"`
+	// Score is the score of the sample, the score of all the mutants is
+	// between Low and High with a 95% confidence.
+	Score float64 `json:"score"`
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+}
+
+// stratum is the mutants of a mutator in a file.
+type stratum struct {
+	key     string
+	mutants []string
+	// the number of mutants sampled.
+	n int
+}
+
+// enumerateMutants returns the IDs of all the mutants of the package, grouped
+// by mutator and file.
+func enumerateMutants(cfg config, coverprofiles []*cover.Profile) ([]*stratum, error) {
+	lp, err := loadPackage(cfg.pkgFull)
+	if err != nil {
+		return nil, err
+	}
+	w := worker{coverprofiles: coverprofiles}
+	strata := make(map[string]*stratum)
+	for name, file := range lp.pkg.Files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		baseName := filepath.Base(name)
+		for _, m := range godzilla.FirstOrderMutants(w.parseInfo(lp, name, file), cfg.mutations) {
+			pos := lp.fset.Position(m.Node.Pos())
+			key := m.Mutator + ":" + baseName
+			if strata[key] == nil {
+				strata[key] = &stratum{key: key}
+			}
+			strata[key].mutants = append(strata[key].mutants, mutantID(m.Mutator, baseName, pos, m.Number))
+		}
+	}
+
+	var sorted []*stratum
+	for _, s := range strata {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].key < sorted[j].key })
+	return sorted, nil
+}
+
+// sampleMutants selects n of the mutants of the strata with rng, the strata
+// get a share of the sample proportional to their size. When n allows it,
+// every stratum gets at least one mutant so that rare mutators are sampled too.
+// It returns the set of selected IDs.
+func sampleMutants(strata []*stratum, n int, rng *rand.Rand) map[string]bool {
+	total, nonEmpty := 0, 0
+	for _, s := range strata {
+		total += len(s.mutants)
+		if len(s.mutants) > 0 {
+			nonEmpty++
+		}
+	}
+
+	// the first mutant of every stratum is set apart, the rest of the
+	// sample is shared among the rest of the mutants.
+	first := 0
+	if n >= nonEmpty {
+		first = 1
+	}
+	for _, s := range strata {
+		s.n = 0
+		if len(s.mutants) > 0 {
+			s.n = first
+		}
+	}
+	n -= first * nonEmpty
+	total -= first * nonEmpty
+
+	// largest remainder allocation of the sample.
+	allocated := 0
+	remainders := make([]float64, len(strata))
+	for i, s := range strata {
+		if total == 0 || len(s.mutants) == 0 {
+			continue
+		}
+		quota := float64(len(s.mutants)-first) * float64(n) / float64(total)
+		remainders[i] = quota - math.Floor(quota)
+		s.n += int(quota)
+		allocated += int(quota)
+	}
+	order := make([]int, len(strata))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return remainders[order[i]] > remainders[order[j]] })
+	for _, i := range order[:n-allocated] {
+		strata[i].n++
+	}
+
+	selected := make(map[string]bool)
+	for _, s := range strata {
+		for _, i := range rng.Perm(len(s.mutants))[:s.n] {
+			selected[s.mutants[i]] = true
+		}
+	}
+	return selected
+}
+
+// selectMutants returns the mutants to test with -sample and -max-mutants, nil
+// to test them all, and the number of mutants.
+func selectMutants(cfg config, coverprofiles []*cover.Profile) (map[string]bool, int) {
+	if *sampleFlag == 0 && *maxMutantsFlag == 0 {
+		return nil, 0
+	}
+	strata, err := enumerateMutants(cfg, coverprofiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	population := 0
+	for _, s := range strata {
+		population += len(s.mutants)
+	}
+
+	n := population
+	if *sampleFlag > 0 {
+		n = int(math.Round(float64(population) * *sampleFlag))
+	}
+	if *maxMutantsFlag > 0 && n > *maxMutantsFlag {
+		n = *maxMutantsFlag
+	}
+	if n == 0 && population > 0 {
+		// a small rate rounds down to no mutant at all.
+		n = 1
+	}
+	if n >= population {
+		return nil, population
+	}
+	fmt.Printf("sampling %d of %d mutants (seed %d)\n", n, population, *seedFlag)
+	return sampleMutants(strata, n, rand.New(rand.NewSource(*seedFlag))), population
+}
+
+// estimateScore estimates the score of the population from the sample, where
+// killed of the total tested mutants are killed. selected mutants were
+// sampled, some of them are not tested, eg. equivalent ones, the same share of
+// the population is assumed not to be testable.
+func estimateScore(killed, total, selected, population int) estimate {
+	e := estimate{
+		Seed:       *seedFlag,
+		Population: population,
+		Sampled:    selected,
+	}
+	if total == 0 {
+		return e
+	}
+	p := float64(killed) / float64(total)
+	e.Score, e.Low, e.High = p*100, p*100, p*100
+	// Wilson score interval, it doesn't shrink to nothing at 0% and 100%
+	// like the normal approximation. The finite population correction
+	// scales the sample size, all the mutants tested leave no uncertainty.
+	testable := float64(population) * float64(total) / float64(selected)
+	correction := 1.0
+	if testable > 1 {
+		correction = math.Max(testable-float64(total), 0) / (testable - 1)
+	}
+	if correction == 0 {
+		return e
+	}
+	const z = 1.96
+	n := float64(total) / correction
+	center := (p + z*z/(2*n)) / (1 + z*z/n)
+	margin := z / (1 + z*z/n) * math.Sqrt(p*(1-p)/n+z*z/(4*n*n))
+	e.Low = math.Max(center-margin, 0) * 100
+	e.High = math.Min(center+margin, 1) * 100
+	return e
+}
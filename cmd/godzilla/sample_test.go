@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSampleMutants(t *testing.T) {
+	newStrata := func(sizes ...int) []*stratum {
+		var strata []*stratum
+		for i, size := range sizes {
+			s := &stratum{key: fmt.Sprint(i)}
+			for j := 0; j < size; j++ {
+				s.mutants = append(s.mutants, fmt.Sprintf("%d:%d", i, j))
+			}
+			strata = append(strata, s)
+		}
+		return strata
+	}
+	for _, tc := range []struct {
+		sizes []int
+		n     int
+		want  []int
+	}{
+		{[]int{10, 10}, 10, []int{5, 5}},
+		// one mutant each and quotas of 4.26, 2.09 and 0.65 for the other 7.
+		{[]int{60, 30, 10}, 10, []int{5, 3, 2}},
+		// one mutant each and quotas of 0.44, 0.44 and 0.11 for the last one,
+		// equal remainders go to the first strata.
+		{[]int{5, 5, 2}, 4, []int{2, 1, 1}},
+		// too small a sample for every stratum to get a mutant.
+		{[]int{1, 1, 1}, 1, []int{1, 0, 0}},
+		{[]int{6, 1, 1}, 2, []int{2, 0, 0}},
+		{[]int{3}, 3, []int{3}},
+	} {
+		strata := newStrata(tc.sizes...)
+		selected := sampleMutants(strata, tc.n, rand.New(rand.NewSource(1)))
+		if len(selected) != tc.n {
+			t.Errorf("%v: %d mutants selected, want %d", tc.sizes, len(selected), tc.n)
+		}
+		for i, s := range strata {
+			n := 0
+			for id := range selected {
+				if strings.HasPrefix(id, s.key+":") {
+					n++
+				}
+			}
+			if s.n != tc.want[i] || n != tc.want[i] {
+				t.Errorf("%v: stratum %d has %d mutants selected (n = %d), want %d", tc.sizes, i, n, s.n, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestEstimateScore(t *testing.T) {
+	for _, tc := range []struct {
+		killed, total, selected, population int
+		score, low, high                    float64
+	}{
+		{0, 0, 10, 100, 0, 0, 0},
+		// every mutant tested, there is no uncertainty.
+		{50, 100, 100, 100, 50, 50, 50},
+		{80, 100, 100, 1000, 80, 71.6, 86.4},
+		{100, 100, 100, 1000, 100, 96.7, 100},
+		// a single mutant tells little about the score.
+		{1, 1, 1, 1000, 100, 20.7, 100},
+		{0, 1, 1, 1000, 0, 0, 79.3},
+	} {
+		e := estimateScore(tc.killed, tc.total, tc.selected, tc.population)
+		if e.Score != tc.score || !near(e.Low, tc.low) || !near(e.High, tc.high) {
+			t.Errorf("estimateScore(%d, %d, %d, %d) = %.1f%% (%.1f%% - %.1f%%), want %.1f%% (%.1f%% - %.1f%%)",
+				tc.killed, tc.total, tc.selected, tc.population, e.Score, e.Low, e.High, tc.score, tc.low, tc.high)
+		}
+	}
+}
+
+// near returns true if a and b are equal to the first decimal.
+func near(a, b float64) bool {
+	return a-b < 0.05 && b-a < 0.05
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const tceSource = `package clamp
+
+const debug = false
+
+func Clamp(x int) int {
+	if x > 10 && debug {
+		return 10
+	}
+	return x
+}
+`
+
+const tceTest = `package clamp
+
+import "testing"
+
+var n = 3
+
+func TestClamp(t *testing.T) {
+	if Clamp(n) != n {
+		t.Fatal("Clamp")
+	}
+}
+`
+
+func TestTrivialCompilerEquivalence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles test binaries")
+	}
+	gopath, err := ioutil.TempDir("", "godzilla")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	dir := filepath.Join(gopath, "src", "clamp")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "clamp_test.go"), []byte(tceTest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "GOPATH="+gopath, "GO111MODULE=off", "GOFLAGS=")
+	binary := filepath.Join(gopath, "clamp.test")
+
+	hash := func(src string) [32]byte {
+		t.Helper()
+		if err := ioutil.WriteFile(filepath.Join(dir, "clamp.go"), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := compileTest(dir, binary, env); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		h, err := hashFile(binary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	original := hash(tceSource)
+	for _, tc := range []struct {
+		from, to   string
+		equivalent bool
+	}{
+		{"x > 10", "x <= 10", true},
+		{"x > 10", "x >= 10", true},
+		{"return x\n", "return x + 1\n", false},
+	} {
+		if h := hash(strings.Replace(tceSource, tc.from, tc.to, 1)); (h == original) != tc.equivalent {
+			t.Errorf("%s to %s: equivalent = %v, want %v", tc.from, tc.to, h == original, tc.equivalent)
+		}
+	}
+}
@@ -0,0 +1,492 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hydroflame/godzilla"
+	"golang.org/x/tools/cover"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// weakHelper is the source of the file added to the package in weak mode. The
+// instrumented expressions call godzillaWeak with their original value and a
+// function per mutant, the mutants reached and infected are written to the file
+// named by the GODZILLA_WEAK environment variable.
+const weakHelper = `package %s
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+var godzillaWeakLog struct {
+	sync.Mutex
+	seen map[string]bool
+}
+
+func godzillaWeak[T comparable](orig T, ids []int, mutants ...func() T) T {
+	for i, mutant := range mutants {
+		godzillaWeakRecord("reached", ids[i])
+		if !godzillaWeakEqual(orig, mutant) {
+			godzillaWeakRecord("infected", ids[i])
+		}
+	}
+	return orig
+}
+
+func godzillaWeakEqual[T comparable](orig T, mutant func() T) (equal bool) {
+	defer func() {
+		// a mutant panicking, eg. dividing by zero, is infected.
+		if recover() != nil {
+			equal = false
+		}
+	}()
+	return orig == mutant()
+}
+
+func godzillaWeakRecord(status string, id int) {
+	godzillaWeakLog.Lock()
+	defer godzillaWeakLog.Unlock()
+	line := fmt.Sprintf("%%s %%d\n", status, id)
+	if godzillaWeakLog.seen[line] {
+		return
+	}
+	if godzillaWeakLog.seen == nil {
+		godzillaWeakLog.seen = make(map[string]bool)
+	}
+	godzillaWeakLog.seen[line] = true
+	f, err := os.OpenFile(os.Getenv("GODZILLA_WEAK"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	f.WriteString(line)
+	f.Close()
+}
+`
+
+// weakStatus is the outcome of a mutant in weak mode.
+type weakStatus struct {
+	// the mutated expression was evaluated.
+	reached bool
+	// the mutated expression evaluated to a different value than the
+	// original at least once.
+	infected bool
+}
+
+// weakPoint is an expression mutant that can be evaluated alongside the
+// original expression.
+type weakPoint struct {
+	key string
+	// the base name of the file and the offsets of the original expression.
+	file       string
+	start, end int
+	pos        token.Position
+	// the source and type of the mutated expression.
+	mutant string
+	typ    string
+}
+
+// mutantKey identifies an expression mutant across the weak and strong runs,
+// it returns the offsets of the original expression and the source of the
+// mutant as well.
+func mutantKey(fset *token.FileSet, name string, orig, mutant ast.Node) (key string, start, end int, src string) {
+	var b bytes.Buffer
+	printer.Fprint(&b, fset, mutant)
+	start, end = fset.Position(orig.Pos()).Offset, fset.Position(orig.End()).Offset
+	src = b.String()
+	return fmt.Sprintf("%s:%d:%d:%s", filepath.Base(name), start, end, src), start, end, src
+}
+
+// weakCollector implements godzilla.MutantTester, it collects the mutants that
+// can be instrumented instead of testing them.
+type weakCollector struct {
+	parseInfo godzilla.ParseInfo
+	fileName  string
+
+	points []weakPoint
+	// the number of mutants that can't be instrumented.
+	skipped int
+}
+
+// Test is called for mutants that aren't expressions, they can't be
+// instrumented.
+func (c *weakCollector) Test() {
+	c.skipped++
+}
+
+// TestMutant records the mutation of orig into mutant if both are side effect
+// free expressions of a basic type.
+func (c *weakCollector) TestMutant(orig, mutant ast.Node) {
+	o, ok := orig.(ast.Expr)
+	if !ok {
+		c.skipped++
+		return
+	}
+	m, ok := mutant.(ast.Expr)
+	if !ok || !sideEffectFree(c.parseInfo.TypesInfo, o) || !sideEffectFree(c.parseInfo.TypesInfo, m) {
+		c.skipped++
+		return
+	}
+
+	// in place mutations keep the original node in the tree, replacements
+	// keep it in orig.
+	tv, ok := c.parseInfo.TypesInfo.Types[m]
+	if !ok {
+		tv, ok = c.parseInfo.TypesInfo.Types[o]
+	}
+	if !ok || tv.Value != nil {
+		c.skipped++
+		return
+	}
+	basic, ok := types.Default(tv.Type).(*types.Basic)
+	if !ok || basic.Info()&types.IsUntyped != 0 || basic.Kind() == types.UnsafePointer {
+		c.skipped++
+		return
+	}
+	// the variables written to can't be replaced with a call, and
+	// replacements, eg. a nil argument to an interface parameter, must have
+	// the type of the original.
+	if isStored(c.parseInfo.File, m) || !c.hasType(m, basic) {
+		c.skipped++
+		return
+	}
+
+	key, start, end, src := mutantKey(c.parseInfo.FileSet, c.fileName, orig, mutant)
+	c.points = append(c.points, weakPoint{
+		key:    key,
+		file:   filepath.Base(c.fileName),
+		start:  start,
+		end:    end,
+		pos:    c.parseInfo.FileSet.Position(orig.Pos()),
+		mutant: src,
+		typ:    basic.Name(),
+	})
+}
+
+// hasType returns true if the mutant can be returned as a value of typ.
+func (c *weakCollector) hasType(mutant ast.Expr, typ types.Type) bool {
+	if _, ok := c.parseInfo.TypesInfo.Types[mutant]; ok {
+		// in place mutations keep the type of the original.
+		return true
+	}
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	err := types.CheckExpr(c.parseInfo.FileSet, c.parseInfo.Package, mutant.Pos(), mutant, info)
+	return err == nil && types.AssignableTo(info.Types[mutant].Type, typ)
+}
+
+// isStored returns true if expr is written to in file, it is the left hand side
+// of an assignment or an increment, the key or value of a range statement or
+// its address is taken.
+func isStored(file *ast.File, expr ast.Expr) bool {
+	path, _ := astutil.PathEnclosingInterval(file, expr.Pos(), expr.Pos())
+	for i, n := range path {
+		if n != expr {
+			continue
+		}
+		if i+1 == len(path) {
+			return false
+		}
+		switch parent := path[i+1].(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range parent.Lhs {
+				if lhs == expr {
+					return true
+				}
+			}
+		case *ast.IncDecStmt:
+			return parent.X == expr
+		case *ast.RangeStmt:
+			return parent.Key == expr || parent.Value == expr
+		case *ast.UnaryExpr:
+			return parent.Op == token.AND
+		}
+		return false
+	}
+	return false
+}
+
+// sideEffectFree returns true if evaluating expr twice is the same as
+// evaluating it once. Calls are only allowed for conversions and the len and
+// cap builtins.
+func sideEffectFree(info *types.Info, expr ast.Expr) bool {
+	free := true
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			tv, ok := info.Types[n.Fun]
+			if !ok || !tv.IsType() && !isLenOrCap(info, n) {
+				free = false
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				free = false
+			}
+		case *ast.FuncLit, *ast.CompositeLit:
+			free = false
+		}
+		return free
+	})
+	return free
+}
+
+// isLenOrCap returns true if call is a call to the len or cap builtins.
+func isLenOrCap(info *types.Info, call *ast.CallExpr) bool {
+	ident, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	builtin, ok := info.Uses[ident].(*types.Builtin)
+	return ok && (builtin.Name() == "len" || builtin.Name() == "cap")
+}
+
+// weakGroup is the set of mutants of the same expression.
+type weakGroup struct {
+	start, end int
+	ids        []int
+}
+
+// weakRounds groups the points of a file by expression and splits the groups
+// in rounds of non overlapping expressions, each round is instrumented and
+// tested separately.
+func weakRounds(points []weakPoint, ids []int) [][]weakGroup {
+	byRange := make(map[[2]int]*weakGroup)
+	var groups []*weakGroup
+	for _, id := range ids {
+		p := points[id]
+		g, ok := byRange[[2]int{p.start, p.end}]
+		if !ok {
+			g = &weakGroup{start: p.start, end: p.end}
+			byRange[[2]int{p.start, p.end}] = g
+			groups = append(groups, g)
+		}
+		g.ids = append(g.ids, id)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].start != groups[j].start {
+			return groups[i].start < groups[j].start
+		}
+		return groups[i].end > groups[j].end
+	})
+
+	var rounds [][]weakGroup
+	for _, g := range groups {
+		placed := false
+		for i, round := range rounds {
+			if round[len(round)-1].end <= g.start {
+				rounds[i] = append(round, *g)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			rounds = append(rounds, []weakGroup{*g})
+		}
+	}
+	return rounds
+}
+
+// instrument returns src with the expressions of groups replaced with calls to
+// godzillaWeak.
+func instrument(src []byte, points []weakPoint, groups []weakGroup) []byte {
+	var b bytes.Buffer
+	last := 0
+	for _, g := range groups {
+		b.Write(src[last:g.start])
+		ids := make([]string, len(g.ids))
+		for i, id := range g.ids {
+			ids[i] = strconv.Itoa(id)
+		}
+		fmt.Fprintf(&b, "godzillaWeak(%s, []int{%s}", src[g.start:g.end], strings.Join(ids, ", "))
+		for _, id := range g.ids {
+			fmt.Fprintf(&b, ", func() %s { return %s }", points[id].typ, points[id].mutant)
+		}
+		b.WriteString(")")
+		last = g.end
+	}
+	b.Write(src[last:])
+	return b.Bytes()
+}
+
+// runWeak runs the weak mutation analysis of the package, it instruments the
+// expression mutants so that a single test run tells which are reached and
+// infected. It returns the status of the mutants indexed by mutantKey.
+func runWeak(cfg config, coverprofiles []*cover.Profile, tmpDir string) map[string]weakStatus {
+	lp, err := loadPackage(cfg.pkgFull)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil
+	}
+
+	// collect the mutants.
+	var points []weakPoint
+	skipped := 0
+	for _, mutator := range cfg.mutations {
+		m := godzilla.Mutators[mutator].M
+		for name, file := range lp.pkg.Files {
+			if strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			c := &weakCollector{
+				parseInfo: godzilla.ParseInfo{
+					FileSet:       lp.fset,
+					CoveredBlocks: coveredBlocks(coverprofiles, name),
+					TypesInfo:     lp.info,
+					Package:       lp.types,
+					File:          file,
+					Uses:          godzilla.LocalUses(lp.info, file),
+				},
+				fileName: name,
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				if n != nil {
+					m(c.parseInfo, n, c)
+				}
+				return true
+			})
+			points = append(points, c.points...)
+			skipped += c.skipped
+		}
+	}
+
+	// mutators can produce the same mutant, eg. a shifted len, keep the
+	// first.
+	seen := make(map[string]bool)
+	unique := points[:0]
+	for _, p := range points {
+		if !seen[p.key] {
+			seen[p.key] = true
+			unique = append(unique, p)
+		}
+	}
+	points = unique
+
+	byFile := make(map[string][]int)
+	for id, p := range points {
+		byFile[p.file] = append(byFile[p.file], id)
+	}
+	rounds := make(map[string][][]weakGroup)
+	n := 0
+	for file, ids := range byFile {
+		rounds[file] = weakRounds(points, ids)
+		if len(rounds[file]) > n {
+			n = len(rounds[file])
+		}
+	}
+
+	// the weak package lives in its own GOPATH, like the mutants.
+	workdir := filepath.Join(tmpDir, "weak")
+	dir := filepath.Join(workdir, "src", cfg.pkg)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil
+	}
+	env := append(os.Environ(), "GOPATH="+workdir+string(filepath.ListSeparator)+cfg.gopath)
+	helper := fmt.Sprintf(weakHelper, lp.pkg.Name)
+	if err := ioutil.WriteFile(filepath.Join(dir, "godzilla_weak.go"), []byte(helper), 0700); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil
+	}
+
+	statuses := make(map[int]weakStatus)
+	for r := 0; r < n; r++ {
+		if err := lp.writeFiles(dir); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return nil
+		}
+		for file, fileRounds := range rounds {
+			if r >= len(fileRounds) {
+				continue
+			}
+			src, err := ioutil.ReadFile(filepath.Join(cfg.pkgFull, file))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				return nil
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, file), instrument(src, points, fileRounds[r]), 0700); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				return nil
+			}
+		}
+
+		out := filepath.Join(workdir, "weak"+strconv.Itoa(r))
+		cmd := exec.Command("go", testArgs(cfg.timeout)...)
+		cmd.Dir = dir
+		cmd.Env = append(env, "GODZILLA_WEAK="+out)
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		if err := cmd.Run(); err != nil {
+			// instrumented expressions keep their original value, the tests
+			// should pass.
+			fmt.Fprintf(os.Stderr, "weak mode: the instrumented tests failed\n%s", output.String())
+		}
+		readWeakStatuses(out, statuses)
+	}
+
+	weak := make(map[string]weakStatus)
+	reached, infected := 0, 0
+	for id, p := range points {
+		status := statuses[id]
+		weak[p.key] = status
+		if status.reached {
+			reached++
+		}
+		if status.infected {
+			infected++
+		} else if status.reached {
+			// weakly alive.
+			fmt.Printf("%s: weak mutant `%s` reached but never infected\n", p.pos, p.mutant)
+		}
+	}
+
+	score := 0.0
+	if len(points) > 0 {
+		score = float64(infected) / float64(len(points)) * 100
+	}
+	fmt.Printf("weak score: %.1f%% (%d infected, %d reached, %d instrumented, %d not instrumented)\n", score, infected, reached, len(points), skipped)
+	return weak
+}
+
+// readWeakStatuses reads the statuses written by godzillaWeak into statuses.
+func readWeakStatuses(name string, statuses map[int]weakStatus) {
+	f, err := os.Open(name)
+	if err != nil {
+		// no mutant was reached.
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		id, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		status := statuses[id]
+		switch fields[0] {
+		case "reached":
+			status.reached = true
+		case "infected":
+			status.infected = true
+		}
+		statuses[id] = status
+	}
+}
@@ -37,7 +37,10 @@
 // be either because your tests are not testing the mutated statement properly
 // (eg. ignoring return values) or godzilla created an equivalent mutant. Code
 // that is not covered is not mutated. godzilla will try to detect equivalent
-// mutant as best it can, however some will slip through the crack.
+// mutant as best it can, however some will slip through the crack. Mutants
+// whose test binary is identical to the one of the original package are
+// reported as equivalent without running the tests, and mutants compiling to
+// the same binary as a previous mutant as duplicate.
 //
 // Most of the output from godzilla is diff -u of the mutated file and the
 // original file
@@ -53,5 +56,5 @@
 //	 		b -= 0
 //	 		return b
 // as well as the final mutation score of your package
-//	score: 50.0% (9 killed, 9 alive, 18 total, 0 skipped)
+//	score: 50.0% (9 killed, 9 alive, 18 total, 0 skipped, 0 timed out, 2 equivalent, 1 duplicate)
 package godzilla
@@ -0,0 +1,448 @@
+package godzilla
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Filter inspects a mutation before it is tested. orig is a copy of the
+// mutated node taken before the mutation and mutant the node of the mutated
+// tree, they are the same kind of node unless the mutator replaced orig. It
+// returns why the mutant is equivalent to the original program, or an empty
+// string.
+type Filter func(parseInfo ParseInfo, orig, mutant ast.Node) string
+
+// Filters are the filters run on mutants before they are tested, indexed by
+// name. Mutants for which a filter returns a reason are discarded.
+var Filters = map[string]Filter{
+	"constfold":    ConstantFoldingFilter,
+	"loopbound":    LoopBoundFilter,
+	"sameoperands": SameOperandsFilter,
+	"unreadvar":    UnreadVariableFilter,
+}
+
+// Discarder is implemented by Testers that want to know about the mutants
+// discarded by Filters.
+type Discarder interface {
+	Discard(filter string, pos token.Position, reason string)
+}
+
+// MutantTester is implemented by Testers that want to know which node was
+// mutated. Mutators going through the filters call TestMutant instead of Test,
+// with the same orig and mutant nodes as the filters.
+type MutantTester interface {
+	Tester
+	TestMutant(orig, mutant ast.Node)
+}
+
+// testMutant runs Filters on the mutation of orig into mutant and tests the
+// mutant if none of them discarded it.
+func testMutant(parseInfo ParseInfo, orig, mutant ast.Node, tester Tester) {
+	names := make([]string, 0, len(Filters))
+	for name := range Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		reason := Filters[name](parseInfo, orig, mutant)
+		if reason == "" {
+			continue
+		}
+		if d, ok := tester.(Discarder); ok {
+			d.Discard(name, parseInfo.FileSet.Position(orig.Pos()), reason)
+		}
+		return
+	}
+	if mt, ok := tester.(MutantTester); ok {
+		mt.TestMutant(orig, mutant)
+		return
+	}
+	tester.Test()
+}
+
+// ancestors returns the nodes enclosing mutant in the mutated file, innermost
+// first. Nodes replacing orig must be positioned at orig.Pos() to be found.
+func ancestors(parseInfo ParseInfo, orig, mutant ast.Node) []ast.Node {
+	if parseInfo.File == nil {
+		return nil
+	}
+	// the mutant might be shorter than orig, only its start is reliable.
+	path, _ := astutil.PathEnclosingInterval(parseInfo.File, orig.Pos(), orig.Pos())
+	for i, n := range path {
+		if n == mutant {
+			return path[i+1:]
+		}
+	}
+	return nil
+}
+
+// operands returns the operands and operator of a binary expression or of an
+// operation assignment.
+func operands(node ast.Node) (x, y ast.Expr, op token.Token, ok bool) {
+	switch n := node.(type) {
+	case *ast.BinaryExpr:
+		return n.X, n.Y, n.Op, true
+	case *ast.AssignStmt:
+		if n.Tok < token.ADD_ASSIGN || n.Tok > token.AND_NOT_ASSIGN || len(n.Lhs) != 1 || len(n.Rhs) != 1 {
+			return nil, nil, token.ILLEGAL, false
+		}
+		// the operation assignment tokens are in the same order as the
+		// operators.
+		return n.Lhs[0], n.Rhs[0], n.Tok - token.ADD_ASSIGN + token.ADD, true
+	}
+	return nil, nil, token.ILLEGAL, false
+}
+
+// swapsOperator returns true if mutant is orig with another operator, the
+// operands are left alone.
+func swapsOperator(orig, mutant ast.Node) bool {
+	x, y, origOp, ok := operands(orig)
+	if !ok {
+		return false
+	}
+	mx, my, mutantOp, ok := operands(mutant)
+	return ok && mx == x && my == y && mutantOp != origOp
+}
+
+// constValue returns the value of expr if it is a constant, or nil.
+func constValue(parseInfo ParseInfo, expr ast.Expr) constant.Value {
+	tv, ok := parseInfo.TypesInfo.Types[expr]
+	if !ok {
+		return nil
+	}
+	return tv.Value
+}
+
+// ConstantFoldingFilter discards operator mutants whose right operand is a
+// constant making both operators identities, eg. `x + c` to `x - c` where c is
+// a named constant equal to 0.
+func ConstantFoldingFilter(parseInfo ParseInfo, orig, mutant ast.Node) string {
+	if !swapsOperator(orig, mutant) {
+		return ""
+	}
+	_, y, origOp, _ := operands(orig)
+	_, _, mutantOp, _ := operands(mutant)
+	v := constValue(parseInfo, y)
+	if v == nil {
+		return ""
+	}
+
+	var identity int64
+	switch {
+	case isPair(origOp, mutantOp, token.ADD, token.SUB), isPair(origOp, mutantOp, token.SHL, token.SHR):
+		identity = 0
+	case isPair(origOp, mutantOp, token.MUL, token.QUO):
+		identity = 1
+	default:
+		return ""
+	}
+	if v.Kind() != constant.Int && v.Kind() != constant.Float {
+		return ""
+	}
+	if !constant.Compare(v, token.EQL, constant.MakeInt64(identity)) {
+		return ""
+	}
+	return fmt.Sprintf("%s is a constant equal to %d, %s and %s are identities", types.ExprString(y), identity, origOp, mutantOp)
+}
+
+// isPair returns true if a and b are x and y, in any order.
+func isPair(a, b, x, y token.Token) bool {
+	return a == x && b == y || a == y && b == x
+}
+
+// UnreadVariableFilter discards mutants of the value assigned to a local
+// variable that is never read. Stores overwritten before being read are not
+// detected, the filter doesn't follow the control flow.
+func UnreadVariableFilter(parseInfo ParseInfo, orig, mutant ast.Node) string {
+	var target ast.Expr
+	if assign, ok := mutant.(*ast.AssignStmt); ok {
+		// operation assignment.
+		if len(assign.Lhs) != 1 {
+			return ""
+		}
+		target = assign.Lhs[0]
+	} else {
+		// only follow operators, a call or a channel receive using the mutated
+		// value might have side effects.
+		var cur ast.Node = mutant
+	loop:
+		for _, n := range ancestors(parseInfo, orig, mutant) {
+			switch n := n.(type) {
+			case *ast.BinaryExpr, *ast.ParenExpr:
+				cur = n
+			case *ast.UnaryExpr:
+				if n.Op == token.ARROW {
+					return ""
+				}
+				cur = n
+			case *ast.AssignStmt:
+				if len(n.Lhs) != len(n.Rhs) {
+					return ""
+				}
+				for i, rhs := range n.Rhs {
+					if rhs == cur {
+						target = n.Lhs[i]
+					}
+				}
+				break loop
+			default:
+				return ""
+			}
+		}
+	}
+
+	ident, ok := target.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	obj := parseInfo.TypesInfo.ObjectOf(ident)
+	v, ok := obj.(*types.Var)
+	if !ok || v.IsField() || v.Pkg() == nil || v.Parent() == v.Pkg().Scope() {
+		return ""
+	}
+	if isRead(parseInfo, v) {
+		return ""
+	}
+	return fmt.Sprintf("%s is never read", ident.Name)
+}
+
+// isRead returns true if v is used anywhere else than as the target of an
+// assignment in the mutated file. Since locals must be used, only parameters
+// and named results are ever not read. Named results are read by naked
+// returns.
+func isRead(parseInfo ParseInfo, v *types.Var) bool {
+	if parseInfo.File == nil {
+		return true
+	}
+
+	writes := make(map[*ast.Ident]bool)
+	read := false
+	ast.Inspect(parseInfo.File, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				break
+			}
+			for _, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					writes[ident] = true
+				}
+			}
+		case *ast.FuncDecl:
+			read = read || nakedReturn(parseInfo, v, n.Type, n.Body)
+		case *ast.FuncLit:
+			read = read || nakedReturn(parseInfo, v, n.Type, n.Body)
+		}
+		return !read
+	})
+	if read {
+		return true
+	}
+
+	for ident, obj := range parseInfo.TypesInfo.Uses {
+		if obj == v && !writes[ident] {
+			return true
+		}
+	}
+	return false
+}
+
+// nakedReturn returns true if v is a named result of the function of type
+// typ and body and if body has a return statement without values.
+func nakedReturn(parseInfo ParseInfo, v *types.Var, typ *ast.FuncType, body *ast.BlockStmt) bool {
+	if typ.Results == nil || body == nil {
+		return false
+	}
+	result := false
+	for _, field := range typ.Results.List {
+		for _, name := range field.Names {
+			result = result || parseInfo.TypesInfo.Defs[name] == v
+		}
+	}
+	if !result {
+		return false
+	}
+
+	naked := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			naked = naked || len(n.Results) == 0
+		}
+		return !naked
+	})
+	return naked
+}
+
+// LoopBoundFilter discards boundary mutants of loop conditions comparing a
+// counter to a constant bound that the counter never equals, eg.
+//
+//	for i := 0; i < 10; i += 3 to for i := 0; i <= 10; i += 3
+func LoopBoundFilter(parseInfo ParseInfo, orig, mutant ast.Node) string {
+	origCond, ok := orig.(*ast.BinaryExpr)
+	if !ok {
+		return ""
+	}
+	cond, ok := mutant.(*ast.BinaryExpr)
+	if !ok || conditionalsBoundaryMutatorTable[origCond.Op] != cond.Op {
+		return ""
+	}
+	path := ancestors(parseInfo, orig, mutant)
+	if len(path) == 0 {
+		return ""
+	}
+	loop, ok := path[0].(*ast.ForStmt)
+	if !ok || loop.Cond != cond {
+		return ""
+	}
+
+	// the counter is the variable compared to a constant.
+	counter, bound := cond.X, cond.Y
+	if constValue(parseInfo, bound) == nil {
+		counter, bound = bound, counter
+	}
+	ident, ok := counter.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	obj := parseInfo.TypesInfo.ObjectOf(ident)
+	end := constValue(parseInfo, bound)
+	start := loopInit(parseInfo, loop.Init, obj)
+	step := loopStep(parseInfo, loop.Post, obj)
+	if obj == nil || end == nil || start == nil || step == nil || end.Kind() != constant.Int {
+		return ""
+	}
+	if writtenIn(parseInfo, obj, loop.Body) {
+		return ""
+	}
+
+	// the counter equals the bound if end-start is a positive multiple of
+	// step.
+	dist := constant.BinaryOp(end, token.SUB, start)
+	if constant.Sign(step) == 0 || constant.Sign(dist) != 0 && constant.Sign(dist) != constant.Sign(step) {
+		return ""
+	}
+	if constant.Sign(constant.BinaryOp(dist, token.REM, step)) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s starts at %s with a step of %s and never equals %s", ident.Name, start, step, end)
+}
+
+// writtenIn returns true if obj is assigned, incremented, decremented or has
+// its address taken in node.
+func writtenIn(parseInfo ParseInfo, obj types.Object, node ast.Node) bool {
+	is := func(expr ast.Expr) bool {
+		ident, ok := expr.(*ast.Ident)
+		return ok && parseInfo.TypesInfo.ObjectOf(ident) == obj
+	}
+	written := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				written = written || is(lhs)
+			}
+		case *ast.IncDecStmt:
+			written = written || is(n.X)
+		case *ast.UnaryExpr:
+			written = written || n.Op == token.AND && is(n.X)
+		}
+		return !written
+	})
+	return written
+}
+
+// loopInit returns the constant value assigned to obj by the init statement of
+// a loop, or nil.
+func loopInit(parseInfo ParseInfo, init ast.Stmt, obj types.Object) constant.Value {
+	assign, ok := init.(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return nil
+	}
+	if assign.Tok != token.DEFINE && assign.Tok != token.ASSIGN {
+		return nil
+	}
+	ident, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok || parseInfo.TypesInfo.ObjectOf(ident) != obj {
+		return nil
+	}
+	v := constValue(parseInfo, assign.Rhs[0])
+	if v == nil || v.Kind() != constant.Int {
+		return nil
+	}
+	return v
+}
+
+// loopStep returns the constant added to obj by the post statement of a loop,
+// or nil.
+func loopStep(parseInfo ParseInfo, post ast.Stmt, obj types.Object) constant.Value {
+	var (
+		target ast.Expr
+		step   constant.Value
+	)
+	switch s := post.(type) {
+	case *ast.IncDecStmt:
+		target, step = s.X, constant.MakeInt64(1)
+		if s.Tok == token.DEC {
+			step = constant.MakeInt64(-1)
+		}
+	case *ast.AssignStmt:
+		if len(s.Lhs) != 1 || len(s.Rhs) != 1 {
+			return nil
+		}
+		target, step = s.Lhs[0], constValue(parseInfo, s.Rhs[0])
+		if step == nil || step.Kind() != constant.Int {
+			return nil
+		}
+		switch s.Tok {
+		case token.ADD_ASSIGN:
+		case token.SUB_ASSIGN:
+			step = constant.UnaryOp(token.SUB, step, 0)
+		default:
+			return nil
+		}
+	default:
+		return nil
+	}
+	ident, ok := target.(*ast.Ident)
+	if !ok || parseInfo.TypesInfo.ObjectOf(ident) != obj {
+		return nil
+	}
+	return step
+}
+
+// SameOperandsFilter discards mutants swapping &, |, && and || when both
+// operands are the same value, eg. `a & a` to `a | a`.
+func SameOperandsFilter(parseInfo ParseInfo, orig, mutant ast.Node) string {
+	if !swapsOperator(orig, mutant) {
+		return ""
+	}
+	x, y, origOp, _ := operands(orig)
+	_, _, mutantOp, _ := operands(mutant)
+	if !isPair(origOp, mutantOp, token.AND, token.OR) && !isPair(origOp, mutantOp, token.LAND, token.LOR) {
+		return ""
+	}
+	if !sameValue(parseInfo, x, y) {
+		return ""
+	}
+	return fmt.Sprintf("%s and %s are equal", types.ExprString(x), types.ExprString(y))
+}
+
+// sameValue returns true if x and y are proven to evaluate to the same value,
+// they are either equal constants or the same side effect free expression.
+func sameValue(parseInfo ParseInfo, x, y ast.Expr) bool {
+	vx, vy := constValue(parseInfo, x), constValue(parseInfo, y)
+	if vx != nil && vy != nil {
+		return constant.Compare(vx, token.EQL, vy)
+	}
+	return isPure(x) && types.ExprString(x) == types.ExprString(y)
+}
@@ -0,0 +1,214 @@
+package godzilla
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+// filterTester counts the mutants tested and discarded.
+type filterTester struct {
+	tested, discarded int
+}
+
+func (f *filterTester) Test() {
+	f.tested++
+}
+
+func (f *filterTester) Discard(filter string, pos token.Position, reason string) {
+	f.discarded++
+}
+
+// parseSource type checks src as the only file of package a, every line of it
+// is covered.
+func parseSource(t *testing.T, src string) ParseInfo {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "a.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("a", fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ParseInfo{
+		FileSet:       fset,
+		CoveredBlocks: []cover.ProfileBlock{{StartLine: 1, StartCol: 1, EndLine: 1 << 20, EndCol: 1, Count: 1}},
+		TypesInfo:     info,
+		Package:       pkg,
+		File:          file,
+	}
+}
+
+// runFilter runs the mutator on every node of src with only the filter
+// enabled and returns the number of mutants tested and discarded.
+func runFilter(t *testing.T, filter, mutator, src string) (tested, discarded int) {
+	t.Helper()
+	parseInfo := parseSource(t, src)
+	file := parseInfo.File
+
+	defer func(filters map[string]Filter) { Filters = filters }(Filters)
+	Filters = map[string]Filter{filter: Filters[filter]}
+
+	var tester filterTester
+	m := Mutators[mutator].M
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n != nil {
+			m(parseInfo, n, &tester)
+		}
+		return true
+	})
+	return tester.tested, tester.discarded
+}
+
+func TestFilters(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		filter, mutator   string
+		src               string
+		tested, discarded int
+	}{
+		{
+			name:   "constant zero",
+			filter: "constfold", mutator: "mathop",
+			src:       "package a\nconst zero = 0\nfunc f(x int) int { return x + zero }",
+			discarded: 1,
+		},
+		{
+			name:   "constant one",
+			filter: "constfold", mutator: "mathop",
+			src:       "package a\nconst one = 1.0\nfunc f(x float64) float64 { return x * one }",
+			discarded: 1,
+		},
+		{
+			name:   "constant shift",
+			filter: "constfold", mutator: "mathop",
+			src:       "package a\nconst none = 0\nfunc f(x uint) uint { return x << none }",
+			discarded: 1,
+		},
+		{
+			name:   "constant two",
+			filter: "constfold", mutator: "mathop",
+			src:    "package a\nconst two = 2\nfunc f(x int) int { return x + two }",
+			tested: 1,
+		},
+		{
+			name:   "variable",
+			filter: "constfold", mutator: "mathop",
+			src:    "package a\nfunc f(x, y int) int { return x + y }",
+			tested: 1,
+		},
+		{
+			name:   "shifted constant",
+			filter: "constfold", mutator: "indexbound",
+			src:    "package a\nfunc f(s []int) int { return s[0] }",
+			tested: 1,
+		},
+		{
+			name:   "dead result",
+			filter: "unreadvar", mutator: "mathop",
+			src:       "package a\nfunc f(a int) (r int) {\n\tr = a + 1\n\treturn 0\n}",
+			discarded: 1,
+		},
+		{
+			name:   "dead parameter",
+			filter: "unreadvar", mutator: "mathopassign",
+			src:       "package a\nfunc f(a, b int) {\n\ta += b\n}",
+			discarded: 1,
+		},
+		{
+			name:   "read result",
+			filter: "unreadvar", mutator: "mathop",
+			src:    "package a\nfunc f(a int) (r int) {\n\tr = a + 1\n\treturn r\n}",
+			tested: 1,
+		},
+		{
+			name:   "naked return",
+			filter: "unreadvar", mutator: "mathop",
+			src:    "package a\nfunc f(a int) (r int) {\n\tr = a + 1\n\treturn\n}",
+			tested: 1,
+		},
+		{
+			name:   "call",
+			filter: "unreadvar", mutator: "mathop",
+			src:    "package a\nfunc g(int) int { return 0 }\nfunc f(a int) (r int) {\n\tr = g(a + 1)\n\treturn 0\n}",
+			tested: 1,
+		},
+		{
+			name:   "bound never reached",
+			filter: "loopbound", mutator: "condbound",
+			src:       "package a\nfunc f() (n int) {\n\tfor i := 0; i < 10; i += 3 {\n\t\tn++\n\t}\n\treturn\n}",
+			discarded: 1,
+		},
+		{
+			name:   "bound reached",
+			filter: "loopbound", mutator: "condbound",
+			src:    "package a\nfunc f() (n int) {\n\tfor i := 0; i < 9; i += 3 {\n\t\tn++\n\t}\n\treturn\n}",
+			tested: 1,
+		},
+		{
+			name:   "counter written",
+			filter: "loopbound", mutator: "condbound",
+			src:    "package a\nfunc f() (n int) {\n\tfor i := 0; i < 10; i += 3 {\n\t\ti--\n\t}\n\treturn\n}",
+			tested: 1,
+		},
+		{
+			name:   "variable bound",
+			filter: "loopbound", mutator: "condbound",
+			src:    "package a\nfunc f(m int) (n int) {\n\tfor i := 0; i < m; i += 3 {\n\t\tn++\n\t}\n\treturn\n}",
+			tested: 1,
+		},
+		{
+			name:   "same bits",
+			filter: "sameoperands", mutator: "mathop",
+			src:       "package a\nfunc f(a int) int { return a & a }",
+			discarded: 1,
+		},
+		{
+			name:   "same booleans",
+			filter: "sameoperands", mutator: "boolop",
+			src:       "package a\nfunc f(a bool) bool { return a || a }",
+			discarded: 1,
+		},
+		{
+			name:   "same constants",
+			filter: "sameoperands", mutator: "mathop",
+			src:       "package a\nconst b, c = 3, 3\nfunc f() int { return b | c }",
+			discarded: 1,
+		},
+		{
+			name:   "different operands",
+			filter: "sameoperands", mutator: "mathop",
+			src:    "package a\nfunc f(a, b int) int { return a & b }",
+			tested: 1,
+		},
+		{
+			name:   "calls",
+			filter: "sameoperands", mutator: "boolop",
+			src:    "package a\nfunc g() bool { return true }\nfunc f() bool { return g() && g() }",
+			tested: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tested, discarded := runFilter(t, tc.filter, tc.mutator, tc.src)
+			if tested != tc.tested || discarded != tc.discarded {
+				t.Errorf("%d tested and %d discarded, want %d and %d", tested, discarded, tc.tested, tc.discarded)
+			}
+		})
+	}
+}
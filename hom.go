@@ -0,0 +1,177 @@
+package godzilla
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// FirstOrderMutant is a mutant generated by a single mutation: the Index-th
+// mutant that Mutator generates when called with Node.
+type FirstOrderMutant struct {
+	Mutator string
+	Node    ast.Node
+	Index   int
+	// Number is the number of the mutant among those Mutator generates for
+	// the nodes starting at the position of Node, counting the mutants
+	// discarded by Filters, so that along with the position it identifies
+	// the mutant whatever the filters.
+	Number int
+	// Func is the function declaration enclosing Node, nil outside functions.
+	Func *ast.FuncDecl
+
+	// the number of mutants generated for Node.
+	count int
+}
+
+// HigherOrderMutant is a mutant combining two first-order mutants.
+type HigherOrderMutant struct {
+	First, Second FirstOrderMutant
+}
+
+// HigherOrderScope tells which first-order mutants can be combined.
+type HigherOrderScope int
+
+const (
+	// SameFunction combines mutants of the same function.
+	SameFunction HigherOrderScope = iota
+	// AnyFunction combines mutants of the same file.
+	AnyFunction
+)
+
+// HigherOrderTester tests higher-order mutants, TestHigherOrder is called with
+// both mutations applied.
+type HigherOrderTester interface {
+	TestHigherOrder(hom HigherOrderMutant)
+}
+
+// countTester counts the mutants without testing them, the mutants discarded by
+// Filters don't call Test.
+type countTester struct {
+	// the numbers of the tested mutants among all the mutants.
+	tested []int
+	n      int
+}
+
+func (c *countTester) Test() {
+	c.tested = append(c.tested, c.n)
+	c.n++
+}
+
+func (c *countTester) Discard(filter string, pos token.Position, reason string) {
+	c.n++
+}
+
+// nthTester calls test with the n-th mutant only.
+type nthTester struct {
+	n, i int
+	test func()
+}
+
+func (t *nthTester) Test() {
+	if t.i == t.n {
+		t.test()
+	}
+	t.i++
+}
+
+// FirstOrderMutants returns the mutants the mutators, named as in Mutators,
+// generate in parseInfo.File, without testing them.
+func FirstOrderMutants(parseInfo ParseInfo, mutators []string) []FirstOrderMutant {
+	var mutants []FirstOrderMutant
+	for _, name := range mutators {
+		m := Mutators[name].M
+		numbers := make(map[token.Pos]int)
+		ast.Inspect(parseInfo.File, func(node ast.Node) bool {
+			if node == nil {
+				return true
+			}
+			var count countTester
+			m(parseInfo, node, &count)
+			for i, n := range count.tested {
+				mutants = append(mutants, FirstOrderMutant{
+					Mutator: name,
+					Node:    node,
+					Index:   i,
+					Number:  numbers[node.Pos()] + n,
+					Func:    enclosingFunc(parseInfo.File, node),
+					count:   len(count.tested),
+				})
+			}
+			numbers[node.Pos()] += count.n
+			return true
+		})
+	}
+	return mutants
+}
+
+// enclosingFunc returns the function declaration of file containing node.
+func enclosingFunc(file *ast.File, node ast.Node) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Pos() <= node.Pos() && node.End() <= fn.End() {
+			return fn
+		}
+	}
+	return nil
+}
+
+// contains returns true if node is in the tree of root.
+func contains(root, node ast.Node) bool {
+	found := false
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n == node {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// apply applies the mutant and calls test. It returns false if the mutator no
+// longer generates the same mutants for the node, eg. because another mutation
+// replaced it.
+func (m FirstOrderMutant) apply(parseInfo ParseInfo, test func()) bool {
+	if !contains(parseInfo.File, m.Node) {
+		return false
+	}
+	mutator := Mutators[m.Mutator].M
+	var count countTester
+	mutator(parseInfo, m.Node, &count)
+	if len(count.tested) != m.count {
+		return false
+	}
+	mutator(parseInfo, m.Node, &nthTester{n: m.Index, test: test})
+	return true
+}
+
+// TestHigherOrder combines the pairs of mutants in scope for which sample
+// returns true, mutants of the same node are never combined. The mutants must
+// come from FirstOrderMutants for the same parseInfo. Pairs whose second
+// mutant is undone by the first, eg. when the first removes the statement of
+// the second, are not tested.
+func TestHigherOrder(parseInfo ParseInfo, mutants []FirstOrderMutant, scope HigherOrderScope, sample func(first, second FirstOrderMutant) bool, tester HigherOrderTester) {
+	for i, first := range mutants {
+		var pairs []FirstOrderMutant
+		for _, second := range mutants[i+1:] {
+			if second.Node == first.Node {
+				continue
+			}
+			if scope == SameFunction && (first.Func == nil || second.Func != first.Func) {
+				continue
+			}
+			if sample(first, second) {
+				pairs = append(pairs, second)
+			}
+		}
+		if len(pairs) == 0 {
+			continue
+		}
+		first.apply(parseInfo, func() {
+			for _, second := range pairs {
+				second := second
+				second.apply(parseInfo, func() {
+					tester.TestHigherOrder(HigherOrderMutant{First: first, Second: second})
+				})
+			}
+		})
+	}
+}
@@ -3,11 +3,16 @@ package godzilla
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/token"
 	"go/types"
 	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/tools/cover"
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 // Mutators maps command line names to their mutators.
@@ -24,6 +29,22 @@ var Mutators = map[string]Desc{
 		M:           SwapSwitchCase,
 		Description: "Swaps switch case conditions.",
 	},
+	"swaptypeswitch": {
+		M:           SwapTypeSwitchCase,
+		Description: "Swaps type switch case bodies.",
+	},
+	"swapselect": {
+		M:           SwapSelectCase,
+		Description: "Swaps select case bodies.",
+	},
+	"selectcaserm": {
+		M:           SelectCaseRemover,
+		Description: "Removes select cases.",
+	},
+	"selectdefaultrm": {
+		M:           SelectDefaultRemover,
+		Description: "Removes the default case of select statements.",
+	},
 	"condbound": {
 		M:           ConditionalsBoundaryMutator,
 		Description: "Adds or remove an equal sign in comparison operators.",
@@ -48,6 +69,134 @@ var Mutators = map[string]Desc{
 		M:           FloatComparisonInverter,
 		Description: "Invert floating point comparisons. eg. `(f0 == f1)` to `!(f0 != f1)`",
 	},
+	"condconst": {
+		M:           ConditionReplacementMutator,
+		Description: "Replaces conditions and operands of && and || with true and false.",
+	},
+	"errcheckrm": {
+		M:           ErrorCheckRemoverMutator,
+		Description: "Removes the body of `if err != nil { return err }` checks.",
+	},
+	"errnil": {
+		M:           ErrorNilReturnMutator,
+		Description: "Replaces returned errors with nil.",
+	},
+	"errunwrap": {
+		M:           ErrorUnwrapMutator,
+		Description: "Replaces fmt.Errorf and errors.Wrap calls with the wrapped error.",
+	},
+	"nilcheck": {
+		M:           NilCheckMutator,
+		Description: "Removes the body of nil guards or forces their branches.",
+	},
+	"lockrm": {
+		M:           LockRemoverMutator,
+		Description: "Removes Lock/Unlock pairs on sync.Mutex and sync.RWMutex.",
+	},
+	"rlock": {
+		M:           RLockMutator,
+		Description: "Changes RLock/RUnlock pairs on sync.RWMutex to Lock/Unlock.",
+	},
+	"wgrm": {
+		M:           WaitGroupMutator,
+		Description: "Removes calls to sync.WaitGroup Add and Done.",
+	},
+	"gosync": {
+		M:           GoSyncMutator,
+		Description: "Changes go statements to synchronous calls.",
+	},
+	"closerm": {
+		M:           CloseRemoverMutator,
+		Description: "Removes calls to close.",
+	},
+	"chancap": {
+		M:           ChanCapMutator,
+		Description: "Makes buffered channels unbuffered and vice versa.",
+	},
+	"branchswap": {
+		M:           BranchSwapMutator,
+		Description: "Swaps break and continue in loops.",
+	},
+	"branchrm": {
+		M:           BranchRemoverMutator,
+		Description: "Removes break and continue statements.",
+	},
+	"loopskip": {
+		M:           LoopIterationMutator,
+		Description: "Skips the first or last iteration of loops, or all the iterations of range loops.",
+	},
+	"slicebound": {
+		M:           SliceBoundaryMutator,
+		Description: "Shifts slice expression bounds by one and drops the low and high bounds.",
+	},
+	"indexbound": {
+		M:           IndexBoundaryMutator,
+		Description: "Shifts slice, array and string indices by one.",
+	},
+	"lenminus": {
+		M:           LenMutator,
+		Description: "Substracts one to the result of len.",
+	},
+	"varswap": {
+		M:           VariableReplacementMutator,
+		Description: "Replaces variables with other variables of the same type in scope.",
+	},
+	"argswap": {
+		M:           ArgumentSwapMutator,
+		Description: "Swaps call arguments of the same type.",
+	},
+	"argzero": {
+		M:           ArgumentZeroMutator,
+		Description: "Replaces call arguments with the zero value of their type.",
+	},
+	"stmtdup": {
+		M:           StatementDuplicationMutator,
+		Description: "Duplicates statements with side effects.",
+	},
+	"strlit": {
+		M:           StringLiteralMutator,
+		Description: "Replaces string literals with \"\", a sentinel or a case changed version.",
+	},
+	"fmtverb": {
+		M:           FormatVerbMutator,
+		Description: "Changes format verbs to %v or drops them in printf like calls.",
+	},
+	"callrepl": {
+		M:           CallReplacementMutator,
+		Description: "Replaces calls to well known functions with close relatives. (eg. strings.HasPrefix to strings.HasSuffix)",
+	},
+	"deferrm": {
+		M:           DeferRemoverMutator,
+		Description: "Removes defer statements.",
+	},
+	"deferimm": {
+		M:           DeferImmediateMutator,
+		Description: "Runs deferred calls immediately.",
+	},
+	"recoverrm": {
+		M:           RecoverRemoverMutator,
+		Description: "Removes calls to recover in deferred functions.",
+	},
+	"complit": {
+		M:           CompositeLitMutator,
+		Description: "Removes fields and elements of composite literals.",
+	},
+	"mapwriterm": {
+		M:           MapWriteRemoverMutator,
+		Description: "Removes writes to maps.",
+	},
+	"assignzero": {
+		M:           AssignZeroMutator,
+		Description: "Replaces assigned values with the zero value of their type.",
+	},
+	"tupleswap": {
+		M:           TupleSwapMutator,
+		Description: "Swaps the variables receiving the results of a call when they have the same type.",
+	},
+	"commaok": {
+		M:           CommaOkMutator,
+		Description: "Drops the ok check of map reads, type assertions and channel receives.",
+	},
 	"inspect": {
 		M: DebugInspect,
 		// This mutator is there so dev can inspect ast.Node structure, it's not
@@ -87,6 +236,12 @@ type ParseInfo struct {
 	FileSet       *token.FileSet
 	CoveredBlocks []cover.ProfileBlock
 	TypesInfo     *types.Info
+	Package       *types.Package
+	// File is the file being mutated.
+	File *ast.File
+	// Uses are the uses of the local variables of File counted by LocalUses,
+	// nil to count them whenever they are needed.
+	Uses map[types.Object]int
 }
 
 // covered returns true if the node is covered.
@@ -211,6 +366,181 @@ func SwapSwitchCase(parseInfo ParseInfo, node ast.Node, tester Tester) {
 	}
 }
 
+// SwapTypeSwitchCase consecutively swaps each type switch case body with the
+// next. Bodies using the variable declared by the type switch are not swapped
+// because it has a different type in each case.
+func SwapTypeSwitchCase(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	stmt, ok := node.(*ast.TypeSwitchStmt)
+	if !ok {
+		return
+	}
+
+	// not enough candidates to swap
+	if len(stmt.Body.List) < 2 {
+		return
+	}
+
+	// with two cases, swapping the second with the first is the same mutant as
+	// swapping the first with the second.
+	n := len(stmt.Body.List)
+	if n == 2 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		j := (i + 1) % len(stmt.Body.List)
+
+		a := stmt.Body.List[i].(*ast.CaseClause)
+		b := stmt.Body.List[j].(*ast.CaseClause)
+
+		if !bodyCovered(parseInfo, a.Body) && !bodyCovered(parseInfo, b.Body) {
+			continue
+		}
+
+		if usesImplicit(parseInfo, a) || usesImplicit(parseInfo, b) {
+			continue
+		}
+
+		a.Body, b.Body = b.Body, a.Body
+
+		tester.Test()
+
+		a.Body, b.Body = b.Body, a.Body
+	}
+}
+
+// bodyCovered returns true if the body of a case is covered. The coverage
+// profile starts case blocks at their first statement, not at the case itself.
+func bodyCovered(parseInfo ParseInfo, body []ast.Stmt) bool {
+	return len(body) > 0 && covered(parseInfo, body[0])
+}
+
+// usesImplicit returns true if the body of a type switch case uses the
+// variable implicitly declared for that case.
+func usesImplicit(parseInfo ParseInfo, clause *ast.CaseClause) bool {
+	obj, ok := parseInfo.TypesInfo.Implicits[clause]
+	if !ok {
+		return false
+	}
+	for _, stmt := range clause.Body {
+		if usedIn(parseInfo, obj, stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+// SwapSelectCase consecutively swaps each select case body with the next.
+// Bodies using variables declared by their communication are not swapped.
+func SwapSelectCase(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	stmt, ok := node.(*ast.SelectStmt)
+	if !ok {
+		return
+	}
+
+	// not enough candidates to swap
+	if len(stmt.Body.List) < 2 {
+		return
+	}
+
+	n := len(stmt.Body.List)
+	if n == 2 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		j := (i + 1) % len(stmt.Body.List)
+
+		a := stmt.Body.List[i].(*ast.CommClause)
+		b := stmt.Body.List[j].(*ast.CommClause)
+
+		if !bodyCovered(parseInfo, a.Body) && !bodyCovered(parseInfo, b.Body) {
+			continue
+		}
+
+		if declaresVars(a.Comm) || declaresVars(b.Comm) {
+			continue
+		}
+
+		a.Body, b.Body = b.Body, a.Body
+
+		tester.Test()
+
+		a.Body, b.Body = b.Body, a.Body
+	}
+}
+
+// declaresVars returns true if stmt declares at least one non blank variable.
+func declaresVars(stmt ast.Stmt) bool {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE {
+		return false
+	}
+	for _, lhs := range assign.Lhs {
+		if ident, ok := lhs.(*ast.Ident); ok && ident.Name != "_" {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectCaseRemover removes, one at a time, each communication case of a
+// select statement.
+func SelectCaseRemover(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	stmt, ok := node.(*ast.SelectStmt)
+	if !ok {
+		return
+	}
+
+	for i, c := range stmt.Body.List {
+		clause := c.(*ast.CommClause)
+		if clause.Comm == nil {
+			// that's the default case.
+			continue
+		}
+		if !clauseCovered(parseInfo, clause) {
+			continue
+		}
+		replaceStmt(&stmt.Body.List, i, nil, tester)
+	}
+}
+
+// clauseCovered returns true if the clause is covered. The coverage block of a
+// clause starts right after its colon, even if its body is empty.
+func clauseCovered(parseInfo ParseInfo, clause *ast.CommClause) bool {
+	return covered(parseInfo, &ast.EmptyStmt{Semicolon: clause.Colon + 1, Implicit: true})
+}
+
+// SelectDefaultRemover removes the default case of select statements, making
+// them blocking.
+func SelectDefaultRemover(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	stmt, ok := node.(*ast.SelectStmt)
+	if !ok {
+		return
+	}
+
+	for i, clause := range stmt.Body.List {
+		if clause.(*ast.CommClause).Comm == nil {
+			replaceStmt(&stmt.Body.List, i, nil, tester)
+			return
+		}
+	}
+}
+
 // SwapIfElse swaps an ast node if body with the following else statement, if it
 // exists, it will not swap the else if body of an if/else if node.
 func SwapIfElse(parseInfo ParseInfo, node ast.Node, tester Tester) {
@@ -270,16 +600,16 @@ func ConditionalsBoundaryMutator(parseInfo ParseInfo, node ast.Node, tester Test
 		return
 	}
 
-	old := expr.Op
+	orig := *expr
 	op, ok := conditionalsBoundaryMutatorTable[expr.Op]
 	if !ok {
 		return
 	}
 	expr.Op = op
 
-	tester.Test()
+	testMutant(parseInfo, &orig, expr, tester)
 
-	expr.Op = old
+	expr.Op = orig.Op
 }
 
 var mathMutatorTable = map[token.Token]token.Token{
@@ -321,7 +651,7 @@ func MathMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
 		return
 	}
 
-	old := expr.Op
+	orig := *expr
 	op, ok := mathMutatorTable[expr.Op]
 	if !ok {
 		return
@@ -348,9 +678,9 @@ func MathMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
 
 	expr.Op = op
 
-	tester.Test()
+	testMutant(parseInfo, &orig, expr, tester)
 
-	expr.Op = old
+	expr.Op = orig.Op
 }
 
 var mathAssignementMutatorTable = map[token.Token]token.Token{
@@ -382,7 +712,7 @@ func MathAssignMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
 		return
 	}
 
-	old := assign.Tok
+	orig := *assign
 	op, ok := mathAssignementMutatorTable[assign.Tok]
 	if !ok {
 		return
@@ -407,9 +737,9 @@ func MathAssignMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
 
 	assign.Tok = op
 
-	tester.Test()
+	testMutant(parseInfo, &orig, assign, tester)
 
-	assign.Tok = old
+	assign.Tok = orig.Tok
 }
 
 var booleanMutatorTable = map[token.Token]token.Token{
@@ -430,16 +760,16 @@ func BooleanOperatorsMutator(parseInfo ParseInfo, node ast.Node, tester Tester)
 		return
 	}
 
-	old := expr.Op
+	orig := *expr
 	op, ok := booleanMutatorTable[expr.Op]
 	if !ok {
 		return
 	}
 	expr.Op = op
 
-	tester.Test()
+	testMutant(parseInfo, &orig, expr, tester)
 
-	expr.Op = old
+	expr.Op = orig.Op
 }
 
 var negateConditionalsMutatorTable = map[token.Token]token.Token{
@@ -464,102 +794,2449 @@ func NegateConditionalsMutator(parseInfo ParseInfo, node ast.Node, tester Tester
 		return
 	}
 
-	old := expr.Op
+	orig := *expr
 	op, ok := negateConditionalsMutatorTable[expr.Op]
 	if !ok {
 		return
 	}
 	expr.Op = op
 
-	tester.Test()
-
-	expr.Op = old
-}
-
-// DebugInspect is a dev mutator used to inspect the ast.Node hierarchy of the
-// ast tree.
-func DebugInspect(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	testMutant(parseInfo, &orig, expr, tester)
 
+	expr.Op = orig.Op
 }
 
-// ReturnValueMutator changes various return value. (eg. numbers become zero)
-func ReturnValueMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+// ConditionReplacementMutator replaces the conditions of if, for and
+// expressionless switch statements, as well as each operand of && and ||, with
+// the constants true and false. The branches of nil checks are forced by
+// NilCheckMutator instead.
+func ConditionReplacementMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
 	if !covered(parseInfo, node) {
 		return
 	}
 
-	if block, ok := node.(*ast.BlockStmt); ok {
-		returnValueMutator(&block.List, parseInfo, tester)
-	}
-
-	// case bodies are not considered BlockStmt.
-	if casec, ok := node.(*ast.CaseClause); ok {
-		returnValueMutator(&casec.Body, parseInfo, tester)
+	switch n := node.(type) {
+	case *ast.IfStmt:
+		// a variable declared in the init statement that is only used in the
+		// condition would make the mutant fail to compile.
+		if isNilCheck(parseInfo, n.Cond) || declaresUnused(parseInfo, n.Init, n.Body, n.Else) {
+			return
+		}
+		replaceCondition(parseInfo, &n.Cond, tester)
+	case *ast.ForStmt:
+		if n.Cond == nil || declaresUnused(parseInfo, n.Init, n.Body) {
+			return
+		}
+		// a loop whose condition is always true only ends through its body,
+		// otherwise the mutant hangs until the test timeout.
+		if leavesLoop(parseInfo, n.Body) {
+			replaceConditionWith(parseInfo, &n.Cond, true, tester)
+		}
+		replaceConditionWith(parseInfo, &n.Cond, false, tester)
+	case *ast.SwitchStmt:
+		// only switch statements without tag have boolean case expressions.
+		if n.Tag != nil || declaresUnused(parseInfo, n.Init, n.Body) {
+			return
+		}
+		for _, stmt := range n.Body.List {
+			clause := stmt.(*ast.CaseClause)
+			for i := range clause.List {
+				replaceCondition(parseInfo, &clause.List[i], tester)
+			}
+		}
+	case *ast.BinaryExpr:
+		if n.Op != token.LAND && n.Op != token.LOR {
+			return
+		}
+		replaceCondition(parseInfo, &n.X, tester)
+		replaceCondition(parseInfo, &n.Y, tester)
 	}
 }
 
-func returnValueMutator(stmts *[]ast.Stmt, parseInfo ParseInfo, tester Tester) {
-	for i, stmt := range *stmts {
-		ret, ok := stmt.(*ast.ReturnStmt)
-		if !ok {
-			continue
-		}
-		for _, expr := range ret.Results {
-			switch e := expr.(type) {
-			case *ast.BasicLit:
-				switch e.Kind {
-				case token.INT, token.FLOAT:
-					repl := "0"
-					if zeroRegexp.Match([]byte(e.Value)) {
-						repl = "1"
-					}
+// replaceCondition replaces the boolean expression pointed to by expr with true
+// then false and tests both mutants.
+func replaceCondition(parseInfo ParseInfo, expr *ast.Expr, tester Tester) {
+	replaceConditionWith(parseInfo, expr, true, tester)
+	replaceConditionWith(parseInfo, expr, false, tester)
+}
 
-					old := e.Value
-					e.Value = repl
+// replaceConditionWith replaces the boolean expression pointed to by expr with
+// the constant b and tests the mutant. If the expression is already that
+// constant the equivalent mutant is skipped.
+func replaceConditionWith(parseInfo ParseInfo, expr *ast.Expr, b bool, tester Tester) {
+	old := *expr
+	if tv, ok := parseInfo.TypesInfo.Types[old]; ok && tv.Value != nil &&
+		tv.Value.Kind() == constant.Bool && constant.BoolVal(tv.Value) == b {
+		return
+	}
 
-					tester.Test()
+	mutant := &ast.Ident{NamePos: old.Pos(), Name: strconv.FormatBool(b)}
+	*expr = mutant
 
-					e.Value = old
-				}
-			case *ast.Ident:
-				switch t := parseInfo.TypesInfo.Types[expr].Type.(type) {
-				case *types.Basic:
-					unusedAssign := &ast.AssignStmt{
-						Lhs: []ast.Expr{&ast.Ident{Name: "_"}},
-						Rhs: []ast.Expr{&ast.Ident{Name: e.Name}},
-						Tok: token.ASSIGN, // assignment token, DEFINE
-						//TokPos: token.Pos,   // position of Tok
-					}
-					old := *stmts
-					nw := make([]ast.Stmt, len(*stmts))
-					copy(nw, old)
+	testMutant(parseInfo, old, mutant, tester)
 
-					nw = append(nw, nil)
-					copy(nw[i+1:], nw[i:])
-					nw[i] = unusedAssign
+	*expr = old
+}
 
-					*stmts = nw
+// leavesLoop returns true if the loop body has a statement that can end the
+// loop: a break or goto, a return or a call to panic.
+func leavesLoop(parseInfo ParseInfo, body *ast.BlockStmt) bool {
+	for _, branch := range loopBranches(body) {
+		if branch.Tok == token.BREAK {
+			return true
+		}
+	}
+	leaves := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			leaves = true
+		case *ast.BranchStmt:
+			// labeled statements might refer to an enclosing loop.
+			leaves = leaves || n.Label != nil
+		case *ast.CallExpr:
+			leaves = leaves || isBuiltin(parseInfo, n, "panic")
+		}
+		return !leaves
+	})
+	return leaves
+}
 
-					tester.Test()
+// declaresUnused returns true if stmt declares a variable that isn't used in
+// any of the given nodes.
+func declaresUnused(parseInfo ParseInfo, stmt ast.Stmt, nodes ...ast.Node) bool {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE {
+		return false
+	}
 
-					*stmts = old
-				case *types.Pointer:
-					fmt.Println(t)
-				case *types.Named:
-					fmt.Println(t)
-				default:
-					fmt.Printf("unknown ident type %T\n", parseInfo.TypesInfo.Types[expr].Type)
-				}
-			default:
-				fmt.Printf("unknown expr type %T\n", expr)
-			}
+	for _, lhs := range assign.Lhs {
+		ident, ok := lhs.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		obj, ok := parseInfo.TypesInfo.Defs[ident]
+		if !ok || obj == nil {
+			// blank identifier or redeclared variable.
+			continue
+		}
+		if !usedIn(parseInfo, obj, nodes...) {
+			return true
 		}
 	}
+	return false
 }
 
-var floatComparisonInverterMap = map[token.Token]token.Token{
-	token.EQL: token.NEQ,
-	token.NEQ: token.EQL,
+// usedIn returns true if obj is used in any of the given nodes.
+func usedIn(parseInfo ParseInfo, obj types.Object, nodes ...ast.Node) bool {
+	used := false
+	for _, node := range nodes {
+		// nodes are often optional fields, eg. IfStmt.Else.
+		if node == nil {
+			continue
+		}
+		ast.Inspect(node, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && parseInfo.TypesInfo.Uses[ident] == obj {
+				used = true
+			}
+			return !used
+		})
+	}
+	return used
+}
+
+// ErrorCheckRemoverMutator empties the body of error checks like
+//	if err != nil {
+//		return err
+//	}
+// to verify the failure paths are actually tested.
+func ErrorCheckRemoverMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	ifstmt, ok := node.(*ast.IfStmt)
+	if !ok {
+		return
+	}
+
+	cond, ok := ifstmt.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.NEQ {
+		return
+	}
+	if !(isError(parseInfo, cond.X) && isNil(parseInfo, cond.Y)) &&
+		!(isNil(parseInfo, cond.X) && isError(parseInfo, cond.Y)) {
+		return
+	}
+
+	// only consider checks that bail out.
+	if len(ifstmt.Body.List) == 0 {
+		return
+	}
+	if _, ok := ifstmt.Body.List[len(ifstmt.Body.List)-1].(*ast.ReturnStmt); !ok {
+		return
+	}
+
+	if declaresUnused(parseInfo, ifstmt.Init, ifstmt.Cond, ifstmt.Else) {
+		return
+	}
+
+	old := ifstmt.Body
+	ifstmt.Body = &ast.BlockStmt{
+		Lbrace: old.Lbrace,
+		Rbrace: old.Rbrace,
+	}
+
+	tester.Test()
+
+	ifstmt.Body = old
+}
+
+// ErrorNilReturnMutator replaces returned errors with nil.
+func ErrorNilReturnMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	ret, ok := node.(*ast.ReturnStmt)
+	if !ok {
+		return
+	}
+
+	for i, expr := range ret.Results {
+		if !isError(parseInfo, expr) || isNil(parseInfo, expr) {
+			continue
+		}
+
+		mutant := &ast.Ident{NamePos: expr.Pos(), Name: "nil"}
+		ret.Results[i] = mutant
+
+		testMutant(parseInfo, expr, mutant, tester)
+
+		ret.Results[i] = expr
+	}
+}
+
+// errorWrappers maps the full name of functions that wrap an error to the
+// index of the wrapped error in their arguments, -1 means the last argument of
+// type error.
+var errorWrappers = map[string]int{
+	"fmt.Errorf":                         -1,
+	"github.com/pkg/errors.Wrap":         0,
+	"github.com/pkg/errors.Wrapf":        0,
+	"github.com/pkg/errors.WithMessage":  0,
+	"github.com/pkg/errors.WithMessagef": 0,
+	"github.com/pkg/errors.WithStack":    0,
+}
+
+// ErrorUnwrapMutator replaces calls that wrap an error with the wrapped error.
+//	fmt.Errorf("reading: %v", err) to error(err)
+//	errors.Wrap(err, "reading")    to error(err)
+func ErrorUnwrapMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return
+	}
+
+	fn := calleeFunc(parseInfo, call)
+	if fn == nil {
+		return
+	}
+	idx, ok := errorWrappers[fn.FullName()]
+	if !ok {
+		return
+	}
+
+	if idx < 0 {
+		for i := len(call.Args) - 1; i >= 0; i-- {
+			if isError(parseInfo, call.Args[i]) {
+				idx = i
+				break
+			}
+		}
+	}
+	if idx < 0 || idx >= len(call.Args) || !isError(parseInfo, call.Args[idx]) {
+		return
+	}
+
+	orig := *call
+
+	// converting the error to error keeps the call expression in place.
+	call.Fun = &ast.Ident{NamePos: orig.Fun.Pos(), Name: "error"}
+	call.Args = []ast.Expr{orig.Args[idx]}
+	call.Ellipsis = token.NoPos
+
+	testMutant(parseInfo, &orig, call, tester)
+
+	call.Fun, call.Args, call.Ellipsis = orig.Fun, orig.Args, orig.Ellipsis
+}
+
+// calleeFunc returns the function or method called by call, or nil if it's not
+// a call to a declared function (eg. a builtin, a conversion or a closure).
+func calleeFunc(parseInfo ParseInfo, call *ast.CallExpr) *types.Func {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return nil
+	}
+
+	fn, _ := parseInfo.TypesInfo.Uses[ident].(*types.Func)
+	return fn
+}
+
+// NilCheckMutator mutates if statements guarded by a comparison against nil,
+// eg. `if p != nil`, by removing the guarded body and by forcing the branches.
+// The branch is forced to false only if there is an else branch, otherwise it
+// is the same as removing the body.
+func NilCheckMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	ifstmt, ok := node.(*ast.IfStmt)
+	if !ok || !isNilCheck(parseInfo, ifstmt.Cond) {
+		return
+	}
+
+	// remove the guarded body
+	if len(ifstmt.Body.List) > 0 && !declaresUnused(parseInfo, ifstmt.Init, ifstmt.Cond, ifstmt.Else) {
+		old := ifstmt.Body
+		ifstmt.Body = &ast.BlockStmt{
+			Lbrace: old.Lbrace,
+			Rbrace: old.Rbrace,
+		}
+
+		tester.Test()
+
+		ifstmt.Body = old
+	}
+
+	// force the branches
+	if declaresUnused(parseInfo, ifstmt.Init, ifstmt.Body, ifstmt.Else) {
+		return
+	}
+	old := ifstmt.Cond
+	for _, b := range []string{"true", "false"} {
+		if b == "false" && ifstmt.Else == nil {
+			continue
+		}
+		ifstmt.Cond = &ast.Ident{NamePos: old.Pos(), Name: b}
+
+		tester.Test()
+
+		ifstmt.Cond = old
+	}
+}
+
+// isNilCheck returns true if cond compares a value to nil. Error checks are
+// left to ErrorCheckRemoverMutator, removing their body is the same mutant.
+func isNilCheck(parseInfo ParseInfo, cond ast.Expr) bool {
+	bin, ok := cond.(*ast.BinaryExpr)
+	if !ok || (bin.Op != token.EQL && bin.Op != token.NEQ) {
+		return false
+	}
+	if isError(parseInfo, bin.X) || isError(parseInfo, bin.Y) {
+		return false
+	}
+	return (isNilable(parseInfo, bin.X) && isNil(parseInfo, bin.Y)) ||
+		(isNil(parseInfo, bin.X) && isNilable(parseInfo, bin.Y))
+}
+
+// LockRemoverMutator removes Lock/Unlock and RLock/RUnlock pairs on
+// sync.Mutex and sync.RWMutex, the unlock might be deferred.
+func LockRemoverMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	stmts := stmtList(node)
+	if stmts == nil {
+		return
+	}
+
+	for i, stmt := range *stmts {
+		if !covered(parseInfo, stmt) {
+			continue
+		}
+		lock := callStmt(stmt)
+		if lock == nil {
+			continue
+		}
+		unlockName, ok := syncLocks[funcName(parseInfo, lock)]
+		if !ok {
+			continue
+		}
+		j := findUnlock(parseInfo, (*stmts)[i+1:], lock, unlockName)
+		if j < 0 {
+			continue
+		}
+		j += i + 1
+
+		mutation := make([]ast.Stmt, 0, len(*stmts)-2)
+		mutation = append(mutation, (*stmts)[:i]...)
+		mutation = append(mutation, (*stmts)[i+1:j]...)
+		mutation = append(mutation, (*stmts)[j+1:]...)
+
+		old := *stmts
+		*stmts = mutation
+
+		tester.Test()
+
+		*stmts = old
+	}
+}
+
+// RLockMutator turns RLock/RUnlock pairs on sync.RWMutex into Lock/Unlock.
+func RLockMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	stmts := stmtList(node)
+	if stmts == nil {
+		return
+	}
+
+	for i, stmt := range *stmts {
+		if !covered(parseInfo, stmt) {
+			continue
+		}
+		rlock := callStmt(stmt)
+		if rlock == nil || funcName(parseInfo, rlock) != "(*sync.RWMutex).RLock" {
+			continue
+		}
+		j := findUnlock(parseInfo, (*stmts)[i+1:], rlock, "(*sync.RWMutex).RUnlock")
+		if j < 0 {
+			continue
+		}
+		runlock := callStmt((*stmts)[i+1+j])
+
+		a := rlock.Fun.(*ast.SelectorExpr).Sel
+		b := runlock.Fun.(*ast.SelectorExpr).Sel
+
+		a.Name, b.Name = "Lock", "Unlock"
+
+		tester.Test()
+
+		a.Name, b.Name = "RLock", "RUnlock"
+	}
+}
+
+// syncLocks maps the sync lock methods to their unlock counterpart.
+var syncLocks = map[string]string{
+	"(*sync.Mutex).Lock":    "(*sync.Mutex).Unlock",
+	"(*sync.RWMutex).Lock":  "(*sync.RWMutex).Unlock",
+	"(*sync.RWMutex).RLock": "(*sync.RWMutex).RUnlock",
+}
+
+// findUnlock returns the index of the statement calling the method unlockName
+// on the receiver of lock, or -1 if there is none.
+func findUnlock(parseInfo ParseInfo, stmts []ast.Stmt, lock *ast.CallExpr, unlockName string) int {
+	sel, ok := lock.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return -1
+	}
+	recv := types.ExprString(sel.X)
+
+	for i, stmt := range stmts {
+		unlock := callStmt(stmt)
+		if unlock == nil || funcName(parseInfo, unlock) != unlockName {
+			continue
+		}
+		if s, ok := unlock.Fun.(*ast.SelectorExpr); ok && types.ExprString(s.X) == recv {
+			return i
+		}
+	}
+	return -1
+}
+
+// WaitGroupMutator removes calls to the Add and Done methods of
+// sync.WaitGroup.
+func WaitGroupMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	stmts := stmtList(node)
+	if stmts == nil {
+		return
+	}
+
+	for i, stmt := range *stmts {
+		if !covered(parseInfo, stmt) {
+			continue
+		}
+		call := callStmt(stmt)
+		if call == nil {
+			continue
+		}
+		switch funcName(parseInfo, call) {
+		case "(*sync.WaitGroup).Add", "(*sync.WaitGroup).Done":
+			replaceStmt(stmts, i, nil, tester)
+		}
+	}
+}
+
+// GoSyncMutator turns go statements into synchronous calls.
+//	go f() to f()
+func GoSyncMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	stmts := stmtList(node)
+	if stmts == nil {
+		return
+	}
+
+	for i, stmt := range *stmts {
+		if !covered(parseInfo, stmt) {
+			continue
+		}
+		gostmt, ok := stmt.(*ast.GoStmt)
+		if !ok {
+			continue
+		}
+		replaceStmt(stmts, i, []ast.Stmt{&ast.ExprStmt{X: gostmt.Call}}, tester)
+	}
+}
+
+// CloseRemoverMutator removes calls to the builtin close, deferred or not.
+func CloseRemoverMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	stmts := stmtList(node)
+	if stmts == nil {
+		return
+	}
+
+	for i, stmt := range *stmts {
+		if !covered(parseInfo, stmt) {
+			continue
+		}
+		if call := callStmt(stmt); call != nil && isBuiltin(parseInfo, call, "close") {
+			replaceStmt(stmts, i, nil, tester)
+		}
+	}
+}
+
+// ChanCapMutator swaps the capacity of buffered and unbuffered channels.
+//	make(chan T, n) to make(chan T)
+//	make(chan T)    to make(chan T, 1)
+func ChanCapMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	call, ok := node.(*ast.CallExpr)
+	if !ok || !isBuiltin(parseInfo, call, "make") || len(call.Args) == 0 {
+		return
+	}
+	if _, ok := call.Args[0].(*ast.ChanType); !ok {
+		return
+	}
+
+	orig := *call
+	if len(call.Args) == 1 || isZero(call.Args[1]) {
+		call.Args = []ast.Expr{orig.Args[0], &ast.BasicLit{Kind: token.INT, Value: "1"}}
+	} else {
+		call.Args = orig.Args[:1]
+	}
+
+	testMutant(parseInfo, &orig, call, tester)
+
+	call.Args = orig.Args
+}
+
+// stmtList returns a pointer to the list of statements of node, or nil if node
+// doesn't hold statements. Case bodies are not considered BlockStmt. The
+// coverage profile starts blocks at their first statement, so the coverage of
+// each statement needs to be checked rather than the one of node.
+func stmtList(node ast.Node) *[]ast.Stmt {
+	switch n := node.(type) {
+	case *ast.BlockStmt:
+		return &n.List
+	case *ast.CaseClause:
+		return &n.Body
+	case *ast.CommClause:
+		return &n.Body
+	}
+	return nil
+}
+
+// replaceStmt replaces the i-th statement of stmts with repl, tests the mutant
+// and restores stmts. A nil repl removes the statement.
+func replaceStmt(stmts *[]ast.Stmt, i int, repl []ast.Stmt, tester Tester) {
+	old := *stmts
+
+	mutation := make([]ast.Stmt, 0, len(old)-1+len(repl))
+	mutation = append(mutation, old[:i]...)
+	mutation = append(mutation, repl...)
+	mutation = append(mutation, old[i+1:]...)
+	*stmts = mutation
+
+	tester.Test()
+
+	*stmts = old
+}
+
+// callStmt returns the call of an expression or defer statement, or nil.
+func callStmt(stmt ast.Stmt) *ast.CallExpr {
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		call, _ := s.X.(*ast.CallExpr)
+		return call
+	case *ast.DeferStmt:
+		return s.Call
+	}
+	return nil
+}
+
+// funcName returns the full name of the function called by call (eg.
+// "(*sync.Mutex).Lock") or an empty string.
+func funcName(parseInfo ParseInfo, call *ast.CallExpr) string {
+	fn := calleeFunc(parseInfo, call)
+	if fn == nil {
+		return ""
+	}
+	return fn.FullName()
+}
+
+// isBuiltin returns true if call is a call to the builtin function name.
+func isBuiltin(parseInfo ParseInfo, call *ast.CallExpr, name string) bool {
+	ident, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	builtin, ok := parseInfo.TypesInfo.Uses[ident].(*types.Builtin)
+	return ok && builtin.Name() == name
+}
+
+// BranchSwapMutator swaps the unlabeled break and continue statements of a
+// loop.
+//	break    to continue
+//	continue to break
+func BranchSwapMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	var body *ast.BlockStmt
+	switch loop := node.(type) {
+	case *ast.ForStmt:
+		body = loop.Body
+	case *ast.RangeStmt:
+		body = loop.Body
+	default:
+		return
+	}
+
+	for _, branch := range loopBranches(body) {
+		if !covered(parseInfo, branch) {
+			continue
+		}
+
+		old := branch.Tok
+		if old == token.BREAK {
+			branch.Tok = token.CONTINUE
+		} else {
+			branch.Tok = token.BREAK
+		}
+
+		tester.Test()
+
+		branch.Tok = old
+	}
+}
+
+// loopBranches returns the unlabeled break and continue statements of a loop
+// body that refer to that loop. Statements inside nested loops are skipped, as
+// well as the break statements inside nested switch and select statements
+// since they refer to those. A continue there, swapped to a break, leaves the
+// switch instead of the loop.
+func loopBranches(body *ast.BlockStmt) []*ast.BranchStmt {
+	return appendLoopBranches(nil, body, true)
+}
+
+// appendLoopBranches appends the branch statements of node referring to the
+// enclosing loop to branches, the break statements only if breaks is true.
+func appendLoopBranches(branches []*ast.BranchStmt, node ast.Node, breaks bool) []*ast.BranchStmt {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ForStmt, *ast.RangeStmt, *ast.FuncLit:
+			return false
+		case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			if n != node {
+				branches = appendLoopBranches(branches, n, false)
+				return false
+			}
+		case *ast.BranchStmt:
+			if n.Label == nil && (n.Tok == token.CONTINUE || (breaks && n.Tok == token.BREAK)) {
+				branches = append(branches, n)
+			}
+		}
+		return true
+	})
+	return branches
+}
+
+// BranchRemoverMutator removes break and continue statements.
+func BranchRemoverMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	stmts := stmtList(node)
+	if stmts == nil {
+		return
+	}
+
+	_, isCase := node.(*ast.CaseClause)
+	_, isComm := node.(*ast.CommClause)
+
+	for i, stmt := range *stmts {
+		branch, ok := stmt.(*ast.BranchStmt)
+		if !ok || (branch.Tok != token.BREAK && branch.Tok != token.CONTINUE) {
+			continue
+		}
+		if !covered(parseInfo, stmt) {
+			continue
+		}
+		// a break ending a case does nothing, removing it is an equivalent
+		// mutant.
+		if (isCase || isComm) && branch.Tok == token.BREAK && branch.Label == nil && i == len(*stmts)-1 {
+			continue
+		}
+		replaceStmt(stmts, i, nil, tester)
+	}
+}
+
+// LoopIterationMutator makes loops skip their first iteration, their last
+// iteration or, for range loops, not run at all. The for loops not running at
+// all are the false conditions of ConditionReplacementMutator.
+func LoopIterationMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	switch loop := node.(type) {
+	case *ast.ForStmt:
+		forIterationMutator(parseInfo, loop, tester)
+	case *ast.RangeStmt:
+		rangeIterationMutator(parseInfo, loop, tester)
+	}
+}
+
+// forIterationMutator handles the loops of the form
+//	for i := a; i < b; i++ {
+func forIterationMutator(parseInfo ParseInfo, loop *ast.ForStmt, tester Tester) {
+	post, ok := loop.Post.(*ast.IncDecStmt)
+	if !ok {
+		return
+	}
+	counter, ok := post.X.(*ast.Ident)
+	if !ok || !isInteger(parseInfo, counter) {
+		return
+	}
+	op := token.ADD
+	if post.Tok == token.DEC {
+		op = token.SUB
+	}
+
+	// skip the first iteration by starting one step later.
+	if init, ok := loop.Init.(*ast.AssignStmt); ok && len(init.Lhs) == 1 && len(init.Rhs) == 1 {
+		if ident, ok := init.Lhs[0].(*ast.Ident); ok && ident.Name == counter.Name {
+			old := init.Rhs[0]
+			init.Rhs[0] = &ast.BinaryExpr{X: old, Op: op, Y: &ast.BasicLit{Kind: token.INT, Value: "1"}}
+
+			tester.Test()
+
+			init.Rhs[0] = old
+		}
+	}
+
+	// skip the last iteration by stopping one step earlier.
+	cond, ok := loop.Cond.(*ast.BinaryExpr)
+	if !ok {
+		return
+	}
+	if ident, ok := cond.X.(*ast.Ident); !ok || ident.Name != counter.Name {
+		return
+	}
+	switch {
+	case post.Tok == token.INC && (cond.Op == token.LSS || cond.Op == token.LEQ):
+		op = token.SUB
+	case post.Tok == token.DEC && (cond.Op == token.GTR || cond.Op == token.GEQ):
+		op = token.ADD
+	default:
+		return
+	}
+
+	old := cond.Y
+	cond.Y = &ast.BinaryExpr{X: old, Op: op, Y: &ast.BasicLit{Kind: token.INT, Value: "1"}}
+
+	tester.Test()
+
+	cond.Y = old
+}
+
+// rangeIterationMutator skips iterations of range loops by inserting a
+// continue at the beginning of their body.
+func rangeIterationMutator(parseInfo ParseInfo, loop *ast.RangeStmt, tester Tester) {
+	if len(loop.Body.List) == 0 {
+		return
+	}
+
+	cont := func() *ast.BlockStmt {
+		return &ast.BlockStmt{List: []ast.Stmt{&ast.BranchStmt{Tok: token.CONTINUE}}}
+	}
+
+	// run zero times, the range expression is still evaluated. The continue
+	// is guarded so that the rest of the body isn't unreachable code for
+	// go vet.
+	insertStmt(&loop.Body.List, 0, &ast.IfStmt{Cond: &ast.Ident{Name: "true"}, Body: cont()}, tester)
+
+	key, ok := loop.Key.(*ast.Ident)
+	if !ok || key.Name == "_" {
+		return
+	}
+
+	t, ok := parseInfo.TypesInfo.Types[loop.X]
+	if !ok {
+		return
+	}
+	var hasLen bool
+	switch u := t.Type.Underlying().(type) {
+	case *types.Slice, *types.Array:
+		hasLen = true
+	case *types.Pointer:
+		if _, ok := u.Elem().Underlying().(*types.Array); !ok {
+			return
+		}
+		hasLen = true
+	case *types.Basic:
+		if u.Info()&(types.IsInteger|types.IsString) == 0 {
+			return
+		}
+	default:
+		return
+	}
+
+	// skip the first iteration
+	insertStmt(&loop.Body.List, 0, &ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  &ast.Ident{Name: key.Name},
+			Op: token.EQL,
+			Y:  &ast.BasicLit{Kind: token.INT, Value: "0"},
+		},
+		Body: cont(),
+	}, tester)
+
+	// skip the last iteration, the range expression is evaluated a second
+	// time so it needs to be free of side effects.
+	if !isPure(loop.X) || isString(parseInfo, loop.X) {
+		return
+	}
+	last := ast.Expr(loop.X)
+	if hasLen {
+		last = &ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{loop.X}}
+	}
+	insertStmt(&loop.Body.List, 0, &ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  &ast.Ident{Name: key.Name},
+			Op: token.EQL,
+			Y:  &ast.BinaryExpr{X: last, Op: token.SUB, Y: &ast.BasicLit{Kind: token.INT, Value: "1"}},
+		},
+		Body: cont(),
+	}, tester)
+}
+
+// insertStmt inserts stmt in stmts before the i-th statement, tests the
+// mutant and restores stmts.
+func insertStmt(stmts *[]ast.Stmt, i int, stmt ast.Stmt, tester Tester) {
+	replaceStmt(stmts, i, []ast.Stmt{stmt, (*stmts)[i]}, tester)
+}
+
+// isPure returns true if evaluating the expression has no side effect. It only
+// recognizes identifiers, field selections, dereferences and literals.
+func isPure(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Ident, *ast.BasicLit:
+		return true
+	case *ast.SelectorExpr:
+		return isPure(e.X)
+	case *ast.StarExpr:
+		return isPure(e.X)
+	case *ast.ParenExpr:
+		return isPure(e.X)
+	}
+	return false
+}
+
+// SliceBoundaryMutator shifts the bounds of slice expressions by one and drops
+// the low and high bounds.
+//	s[i:j] to s[i+1:j], s[i-1:j], s[i:j+1], s[i:j-1], s[:j] and s[i:]
+func SliceBoundaryMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	expr, ok := node.(*ast.SliceExpr)
+	if !ok {
+		return
+	}
+
+	length := constLen(parseInfo, expr.X)
+	bounds := []*ast.Expr{&expr.Low, &expr.High, &expr.Max}
+	for k, bound := range bounds {
+		if *bound == nil || !isInteger(parseInfo, *bound) {
+			continue
+		}
+
+		for _, delta := range []int64{1, -1} {
+			// constant indices are checked by the compiler, they must stay
+			// in range and ordered.
+			valid := true
+			prev := int64(0)
+			for l, b := range bounds {
+				if *b == nil {
+					continue
+				}
+				v, ok := constInt(parseInfo, *b)
+				if !ok {
+					continue
+				}
+				if l == k {
+					v += delta
+				}
+				if v < prev || (length >= 0 && v > length) {
+					valid = false
+				}
+				prev = v
+			}
+			if !valid {
+				continue
+			}
+
+			shiftExpr(parseInfo, bound, delta, tester)
+		}
+	}
+
+	if expr.Slice3 {
+		return
+	}
+
+	// drop the low bound, unless it's already 0.
+	if v, ok := constInt(parseInfo, expr.Low); expr.Low != nil && !(ok && v == 0) {
+		orig := *expr
+		expr.Low = nil
+
+		testMutant(parseInfo, &orig, expr, tester)
+
+		expr.Low = orig.Low
+	}
+
+	// drop the high bound, unless it's already len(s).
+	if expr.High != nil && !isLenOf(parseInfo, expr.High, expr.X) {
+		orig := *expr
+		expr.High = nil
+
+		testMutant(parseInfo, &orig, expr, tester)
+
+		expr.High = orig.High
+	}
+}
+
+// IndexBoundaryMutator shifts the index of slice, array and string index
+// expressions by one.
+//	s[i] to s[i+1] and s[i-1]
+func IndexBoundaryMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	expr, ok := node.(*ast.IndexExpr)
+	if !ok || !isInteger(parseInfo, expr.Index) || !isSequence(parseInfo, expr.X) {
+		return
+	}
+
+	length := constLen(parseInfo, expr.X)
+	for _, delta := range []int64{1, -1} {
+		if v, ok := constInt(parseInfo, expr.Index); ok {
+			if v+delta < 0 || (length >= 0 && v+delta >= length) {
+				continue
+			}
+		}
+
+		shiftExpr(parseInfo, &expr.Index, delta, tester)
+	}
+}
+
+// isSequence returns true if expr is a slice, an array, a pointer to an array or
+// a string, whose indices are integers.
+func isSequence(parseInfo ParseInfo, expr ast.Expr) bool {
+	t, ok := parseInfo.TypesInfo.Types[expr]
+	if !ok {
+		return false
+	}
+	switch u := t.Type.Underlying().(type) {
+	case *types.Slice, *types.Array:
+		return true
+	case *types.Pointer:
+		_, ok := u.Elem().Underlying().(*types.Array)
+		return ok
+	case *types.Basic:
+		return u.Info()&types.IsString != 0
+	}
+	// maps and generic instantiations.
+	return false
+}
+
+// LenMutator substracts one to the result of len. The slice bounds and
+// indices, as well as the len added to them, are already shifted by
+// SliceBoundaryMutator and IndexBoundaryMutator.
+//	len(s) to len(s)-1
+func LenMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	for _, expr := range childExprs(node) {
+		call, ok := (*expr).(*ast.CallExpr)
+		if !ok || !isBuiltin(parseInfo, call, "len") || isBound(parseInfo, node, expr) || isShiftedBound(parseInfo, node) {
+			continue
+		}
+		// len of an array is a constant, changing it might not compile.
+		if t, ok := parseInfo.TypesInfo.Types[call]; !ok || t.Value != nil {
+			continue
+		}
+
+		shiftExpr(parseInfo, expr, -1, tester)
+	}
+}
+
+// isBound returns true if expr is a bound of the slice expression node or the
+// index of the index expression node.
+func isBound(parseInfo ParseInfo, node ast.Node, expr *ast.Expr) bool {
+	switch n := node.(type) {
+	case *ast.SliceExpr:
+		return expr == &n.Low || expr == &n.High || expr == &n.Max
+	case *ast.IndexExpr:
+		return expr == &n.Index && isSequence(parseInfo, n.X)
+	}
+	return false
+}
+
+// isShiftedBound returns true if node is an addition or a substraction used as
+// a slice bound or an index, eg. s[len(s)-1]. Shifting one of its operands is
+// the same as shifting the bound.
+func isShiftedBound(parseInfo ParseInfo, node ast.Node) bool {
+	bin, ok := node.(*ast.BinaryExpr)
+	if !ok || (bin.Op != token.ADD && bin.Op != token.SUB) {
+		return false
+	}
+	path, _ := astutil.PathEnclosingInterval(parseInfo.File, bin.Pos(), bin.End())
+	if len(path) < 2 || path[0] != bin {
+		return false
+	}
+	for _, expr := range childExprs(path[1]) {
+		if *expr == bin {
+			return isBound(parseInfo, path[1], expr)
+		}
+	}
+	return false
+}
+
+// shiftExpr adds delta to the integer expression pointed to by expr, tests
+// the mutant and restores expr.
+func shiftExpr(parseInfo ParseInfo, expr *ast.Expr, delta int64, tester Tester) {
+	op := token.ADD
+	if delta < 0 {
+		op, delta = token.SUB, -delta
+	}
+
+	old := *expr
+	mutant := &ast.BinaryExpr{
+		X:  old,
+		Op: op,
+		Y:  &ast.BasicLit{ValuePos: old.End(), Kind: token.INT, Value: strconv.FormatInt(delta, 10)},
+	}
+	*expr = mutant
+
+	testMutant(parseInfo, old, mutant, tester)
+
+	*expr = old
+}
+
+// constInt returns the value of an integer constant expression.
+func constInt(parseInfo ParseInfo, expr ast.Expr) (int64, bool) {
+	if expr == nil {
+		return 0, false
+	}
+	t, ok := parseInfo.TypesInfo.Types[expr]
+	if !ok || t.Value == nil || t.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	return constant.Int64Val(t.Value)
+}
+
+// constLen returns the length of expr if it is known at compile time (arrays
+// and constant strings), or -1.
+func constLen(parseInfo ParseInfo, expr ast.Expr) int64 {
+	t, ok := parseInfo.TypesInfo.Types[expr]
+	if !ok {
+		return -1
+	}
+	if t.Value != nil && t.Value.Kind() == constant.String {
+		return int64(len(constant.StringVal(t.Value)))
+	}
+
+	u := t.Type.Underlying()
+	if p, ok := u.(*types.Pointer); ok {
+		u = p.Elem().Underlying()
+	}
+	if a, ok := u.(*types.Array); ok {
+		return a.Len()
+	}
+	return -1
+}
+
+// isLenOf returns true if expr is `len(x)`.
+func isLenOf(parseInfo ParseInfo, expr, x ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 || !isBuiltin(parseInfo, call, "len") {
+		return false
+	}
+	return types.ExprString(call.Args[0]) == types.ExprString(x)
+}
+
+// childExprs returns pointers to the expressions directly held by node, so
+// mutators can replace an expression with one of a different kind.
+func childExprs(node ast.Node) []*ast.Expr {
+	var exprs []*ast.Expr
+	add := func(list []ast.Expr) {
+		for i := range list {
+			exprs = append(exprs, &list[i])
+		}
+	}
+
+	switch n := node.(type) {
+	case *ast.BinaryExpr:
+		exprs = append(exprs, &n.X, &n.Y)
+	case *ast.UnaryExpr:
+		exprs = append(exprs, &n.X)
+	case *ast.ParenExpr:
+		exprs = append(exprs, &n.X)
+	case *ast.StarExpr:
+		exprs = append(exprs, &n.X)
+	case *ast.CallExpr:
+		add(n.Args)
+	case *ast.IndexExpr:
+		exprs = append(exprs, &n.Index)
+	case *ast.SliceExpr:
+		for _, e := range []*ast.Expr{&n.Low, &n.High, &n.Max} {
+			if *e != nil {
+				exprs = append(exprs, e)
+			}
+		}
+	case *ast.KeyValueExpr:
+		exprs = append(exprs, &n.Value)
+	case *ast.CompositeLit:
+		add(n.Elts)
+	case *ast.AssignStmt:
+		add(n.Rhs)
+	case *ast.ReturnStmt:
+		add(n.Results)
+	case *ast.ValueSpec:
+		add(n.Values)
+	case *ast.SendStmt:
+		exprs = append(exprs, &n.Value)
+	case *ast.ExprStmt:
+		exprs = append(exprs, &n.X)
+	case *ast.CaseClause:
+		add(n.List)
+	case *ast.SwitchStmt:
+		if n.Tag != nil {
+			exprs = append(exprs, &n.Tag)
+		}
+	case *ast.IfStmt:
+		exprs = append(exprs, &n.Cond)
+	case *ast.ForStmt:
+		if n.Cond != nil {
+			exprs = append(exprs, &n.Cond)
+		}
+	}
+	return exprs
+}
+
+// maxVariableReplacements is the maximum number of mutants the variable
+// replacement mutator generates for a single identifier.
+const maxVariableReplacements = 3
+
+// VariableReplacementMutator replaces the use of a variable with another
+// variable of identical type from the same scope, eg. `a` with `b`. Closer
+// scopes are tried first and at most maxVariableReplacements mutants are
+// generated per identifier.
+func VariableReplacementMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	ident, ok := node.(*ast.Ident)
+	if !ok {
+		return
+	}
+	obj, ok := parseInfo.TypesInfo.Uses[ident].(*types.Var)
+	if !ok || obj.IsField() || obj.Pkg() == nil {
+		return
+	}
+
+	// replacing the only use of a local variable would not compile, unless
+	// the identifier is assigned.
+	if obj.Parent() != obj.Pkg().Scope() && countUses(parseInfo, obj) < 2 && !isAssigned(parseInfo, ident) {
+		return
+	}
+
+	scope := obj.Pkg().Scope().Innermost(ident.Pos())
+	if scope == nil {
+		return
+	}
+
+	old := ident.Name
+	seen := map[string]bool{old: true}
+	n := 0
+	for s := scope; s != nil && s != types.Universe; s = s.Parent() {
+		for _, name := range s.Names() {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			// the closest declaration visible from the identifier.
+			_, other := scope.LookupParent(name, ident.Pos())
+			v, ok := other.(*types.Var)
+			if !ok || v.IsField() || !types.Identical(v.Type(), obj.Type()) {
+				continue
+			}
+
+			orig := *ident
+			ident.Name = name
+
+			testMutant(parseInfo, &orig, ident, tester)
+
+			ident.Name = old
+
+			n++
+			if n == maxVariableReplacements {
+				return
+			}
+		}
+	}
+}
+
+// countUses returns the number of times the local variable obj is used in the
+// file being mutated, assignments to it don't count as uses. The uses of
+// parseInfo.Uses are counted once per file, otherwise the file is inspected.
+func countUses(parseInfo ParseInfo, obj types.Object) int {
+	if parseInfo.Uses != nil {
+		return parseInfo.Uses[obj]
+	}
+	return LocalUses(parseInfo.TypesInfo, parseInfo.File)[obj]
+}
+
+// isAssigned returns true if ident is assigned by an assignment or an
+// increment statement.
+func isAssigned(parseInfo ParseInfo, ident *ast.Ident) bool {
+	if parseInfo.File == nil {
+		return false
+	}
+	path, _ := astutil.PathEnclosingInterval(parseInfo.File, ident.Pos(), ident.End())
+	if len(path) < 2 {
+		return false
+	}
+	switch parent := path[1].(type) {
+	case *ast.AssignStmt:
+		for _, lhs := range parent.Lhs {
+			if lhs == ident {
+				return true
+			}
+		}
+	case *ast.IncDecStmt:
+		return parent.X == ident
+	}
+	return false
+}
+
+// LocalUses counts the uses of the local variables in node. Like for the
+// compiler, assigning a variable doesn't use it.
+func LocalUses(info *types.Info, node ast.Node) map[types.Object]int {
+	assigned := make(map[*ast.Ident]bool)
+	uses := make(map[types.Object]int)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					assigned[ident] = true
+				}
+			}
+		case *ast.IncDecStmt:
+			if ident, ok := n.X.(*ast.Ident); ok {
+				assigned[ident] = true
+			}
+		case *ast.Ident:
+			obj, ok := info.Uses[n].(*types.Var)
+			if ok && !assigned[n] && !obj.IsField() && obj.Pkg() != nil && obj.Parent() != obj.Pkg().Scope() {
+				uses[obj]++
+			}
+		}
+		return true
+	})
+	return uses
+}
+
+// ArgumentSwapMutator swaps two arguments of a call when they are of the
+// same type, eg. `copy(dst, src)` to `copy(src, dst)`.
+func ArgumentSwapMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	sig := callSignature(parseInfo, call)
+	if sig == nil {
+		return
+	}
+
+	for i := range call.Args {
+		for j := i + 1; j < len(call.Args); j++ {
+			a, b := call.Args[i], call.Args[j]
+			ta, ok := parseInfo.TypesInfo.Types[a]
+			if !ok {
+				continue
+			}
+			tb, ok := parseInfo.TypesInfo.Types[b]
+			if !ok || !types.Identical(ta.Type, tb.Type) {
+				continue
+			}
+			pa, pb := paramType(sig, call, i), paramType(sig, call, j)
+			if pa == nil || pb == nil || !types.AssignableTo(ta.Type, pb) || !types.AssignableTo(tb.Type, pa) {
+				continue
+			}
+			// swapping identical arguments is an equivalent mutant.
+			if types.ExprString(a) == types.ExprString(b) {
+				continue
+			}
+
+			orig := *call
+			orig.Args = append([]ast.Expr(nil), call.Args...)
+			call.Args[i], call.Args[j] = b, a
+
+			testMutant(parseInfo, &orig, call, tester)
+
+			call.Args[i], call.Args[j] = a, b
+		}
+	}
+}
+
+// ArgumentZeroMutator replaces, one at a time, each argument of a call with
+// the zero value of the parameter type. The format argument of printf like
+// functions is left to FormatVerbMutator, an empty format with arguments
+// fails go vet.
+func ArgumentZeroMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	// the signature of builtins depends on their arguments, eg. `len(nil)`
+	// doesn't compile.
+	if t, ok := parseInfo.TypesInfo.Types[call.Fun]; !ok || t.IsBuiltin() {
+		return
+	}
+	sig := callSignature(parseInfo, call)
+	if sig == nil {
+		return
+	}
+	format, ok := formatFuncs[funcName(parseInfo, call)]
+	if !ok {
+		format = -1
+	}
+
+	for i, arg := range call.Args {
+		if i == format || isZeroValue(parseInfo, arg) {
+			continue
+		}
+		t := paramType(sig, call, i)
+		if t == nil {
+			continue
+		}
+		zero := zeroValue(parseInfo, t, arg.Pos())
+		if zero == nil {
+			continue
+		}
+
+		call.Args[i] = zero
+
+		testMutant(parseInfo, arg, zero, tester)
+
+		call.Args[i] = arg
+	}
+}
+
+// callSignature returns the signature of the function called by call, or nil
+// if call is a conversion or a call returning multiple values passed as
+// arguments (eg. `f(g())`).
+func callSignature(parseInfo ParseInfo, call *ast.CallExpr) *types.Signature {
+	t, ok := parseInfo.TypesInfo.Types[call.Fun]
+	if !ok || t.IsType() {
+		return nil
+	}
+	sig, ok := t.Type.Underlying().(*types.Signature)
+	if !ok {
+		return nil
+	}
+	if len(call.Args) == 1 {
+		if _, ok := parseInfo.TypesInfo.Types[call.Args[0]].Type.(*types.Tuple); ok {
+			return nil
+		}
+	}
+	return sig
+}
+
+// paramType returns the type of the parameter receiving the i-th argument of
+// call.
+func paramType(sig *types.Signature, call *ast.CallExpr, i int) types.Type {
+	params := sig.Params()
+	if sig.Variadic() && i >= params.Len()-1 {
+		last := params.At(params.Len() - 1).Type()
+		if call.Ellipsis.IsValid() {
+			return last
+		}
+		slice, ok := last.(*types.Slice)
+		if !ok {
+			// append([]byte, string...)
+			return nil
+		}
+		return slice.Elem()
+	}
+	if i >= params.Len() {
+		return nil
+	}
+	return params.At(i).Type()
+}
+
+// zeroValue returns an expression of the zero value of t positioned at pos, or
+// nil if it can't be expressed in the file being mutated (eg. type parameters or
+// unexported types of another package).
+func zeroValue(parseInfo ParseInfo, t types.Type, pos token.Pos) ast.Expr {
+	if _, ok := t.(*types.TypeParam); ok {
+		return nil
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return &ast.Ident{NamePos: pos, Name: "false"}
+		case u.Info()&types.IsNumeric != 0:
+			return &ast.BasicLit{ValuePos: pos, Kind: token.INT, Value: "0"}
+		case u.Info()&types.IsString != 0:
+			return &ast.BasicLit{ValuePos: pos, Kind: token.STRING, Value: `""`}
+		case u.Kind() == types.UnsafePointer:
+			return &ast.Ident{NamePos: pos, Name: "nil"}
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return &ast.Ident{NamePos: pos, Name: "nil"}
+	case *types.Struct, *types.Array:
+		named, ok := t.(*types.Named)
+		if !ok || named.TypeArgs().Len() > 0 {
+			return nil
+		}
+		obj := named.Obj()
+		if obj.Pkg() == nil {
+			return nil
+		}
+		var typ ast.Expr = &ast.Ident{NamePos: pos, Name: obj.Name()}
+		if obj.Pkg() != parseInfo.Package {
+			// the type of another package can be reached without importing
+			// it, eg. as the type of a field.
+			name, ok := importName(parseInfo, obj.Pkg())
+			if !ok || !obj.Exported() {
+				return nil
+			}
+			typ = &ast.SelectorExpr{X: &ast.Ident{NamePos: pos, Name: name}, Sel: &ast.Ident{Name: obj.Name()}}
+		}
+		return &ast.CompositeLit{Type: typ}
+	}
+	return nil
+}
+
+// importName returns the name under which the file being mutated imports pkg,
+// or false if it doesn't import it or imports it with a dot.
+func importName(parseInfo ParseInfo, pkg *types.Package) (string, bool) {
+	if parseInfo.File == nil {
+		return "", false
+	}
+	for _, spec := range parseInfo.File.Imports {
+		obj := parseInfo.TypesInfo.Implicits[spec]
+		if spec.Name != nil {
+			obj = parseInfo.TypesInfo.Defs[spec.Name]
+		}
+		name, ok := obj.(*types.PkgName)
+		if ok && name.Imported() == pkg && name.Name() != "_" {
+			return name.Name(), true
+		}
+	}
+	return "", false
+}
+
+// isZeroValue returns true if expr is nil, an empty struct or array literal or
+// a constant equal to the zero value of its type.
+func isZeroValue(parseInfo ParseInfo, expr ast.Expr) bool {
+	t, ok := parseInfo.TypesInfo.Types[expr]
+	if !ok {
+		return false
+	}
+	if lit, ok := expr.(*ast.CompositeLit); ok && len(lit.Elts) == 0 {
+		switch t.Type.Underlying().(type) {
+		case *types.Struct, *types.Array:
+			return true
+		}
+	}
+	if t.IsNil() {
+		return true
+	}
+	if t.Value == nil {
+		return false
+	}
+	switch t.Value.Kind() {
+	case constant.Bool:
+		return !constant.BoolVal(t.Value)
+	case constant.String:
+		return constant.StringVal(t.Value) == ""
+	case constant.Int, constant.Float, constant.Complex:
+		return constant.Sign(t.Value) == 0
+	}
+	return false
+}
+
+// StatementDuplicationMutator duplicates statements with side effects, eg.
+// increments, channel sends, method calls and writes that depend on the
+// written value like `s = append(s, v)`.
+func StatementDuplicationMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	stmts := stmtList(node)
+	if stmts == nil {
+		return
+	}
+
+	for i, stmt := range *stmts {
+		if !covered(parseInfo, stmt) || !isRepeatable(parseInfo, stmt) {
+			continue
+		}
+		replaceStmt(stmts, i, []ast.Stmt{stmt, stmt}, tester)
+	}
+}
+
+// isRepeatable returns true if executing stmt twice might have a different
+// effect than executing it once, and if the duplicated statement compiles.
+func isRepeatable(parseInfo ParseInfo, stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.IncDecStmt, *ast.SendStmt:
+		return true
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		selection, ok := parseInfo.TypesInfo.Selections[sel]
+		return ok && selection.Kind() == types.MethodVal
+	case *ast.AssignStmt:
+		switch s.Tok {
+		case token.DEFINE:
+			// declaring twice doesn't compile.
+			return false
+		case token.ASSIGN, token.AND_ASSIGN, token.OR_ASSIGN, token.AND_NOT_ASSIGN:
+			// these are idempotent unless the value depends on the
+			// written variables or on a call.
+			for _, rhs := range s.Rhs {
+				if hasCall(parseInfo, rhs) {
+					return true
+				}
+			}
+			if s.Tok != token.ASSIGN {
+				return false
+			}
+			for _, lhs := range s.Lhs {
+				obj := rootObject(parseInfo, lhs)
+				if obj == nil {
+					continue
+				}
+				for _, rhs := range s.Rhs {
+					if usedIn(parseInfo, obj, rhs) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// hasCall returns true if expr contains a function call, conversions and
+// builtins other than append are not considered calls.
+func hasCall(parseInfo ParseInfo, expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return !found
+		}
+		if t, ok := parseInfo.TypesInfo.Types[call.Fun]; ok && (t.IsType() || (t.IsBuiltin() && !isBuiltin(parseInfo, call, "append"))) {
+			return true
+		}
+		found = true
+		return false
+	})
+	return found
+}
+
+// rootObject returns the variable at the root of an assignable expression, eg.
+// `s` for `s.f[i]`.
+func rootObject(parseInfo ParseInfo, expr ast.Expr) types.Object {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return parseInfo.TypesInfo.ObjectOf(e)
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// stringSentinel is the value StringLiteralMutator replaces strings with.
+const stringSentinel = "godzilla"
+
+// StringLiteralMutator replaces string literals with the empty string, with a
+// sentinel value and with their upper (or lower) case version. Struct tags and
+// import paths are not expressions so they are never mutated, neither are
+// comments and the directives they hold. Format strings are left to
+// FormatVerbMutator, changing them would fail the printf check of go vet run by
+// go test.
+func StringLiteralMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	lit, ok := node.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return
+	}
+	if _, ok := parseInfo.TypesInfo.Types[lit]; !ok {
+		return
+	}
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return
+	}
+	if isFormatArg(parseInfo, lit) {
+		return
+	}
+
+	replacements := []string{"", stringSentinel}
+	if upper := strings.ToUpper(value); upper != value {
+		replacements = append(replacements, upper)
+	} else if lower := strings.ToLower(value); lower != value {
+		replacements = append(replacements, lower)
+	}
+
+	orig := *lit
+	for _, repl := range replacements {
+		if repl == value {
+			continue
+		}
+
+		lit.Value = strconv.Quote(repl)
+
+		testMutant(parseInfo, &orig, lit, tester)
+
+		lit.Value = orig.Value
+	}
+}
+
+// isFormatArg returns true if lit is the format argument of a call to one of
+// formatFuncs.
+func isFormatArg(parseInfo ParseInfo, lit *ast.BasicLit) bool {
+	if parseInfo.File == nil {
+		return false
+	}
+	path, _ := astutil.PathEnclosingInterval(parseInfo.File, lit.Pos(), lit.End())
+	if len(path) < 2 {
+		return false
+	}
+	call, ok := path[1].(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	idx, ok := formatFuncs[funcName(parseInfo, call)]
+	return ok && idx < len(call.Args) && call.Args[idx] == lit
+}
+
+// formatFuncs maps the full name of printf like functions to the index of
+// their format argument.
+var formatFuncs = map[string]int{
+	"fmt.Errorf":           0,
+	"fmt.Fprintf":          1,
+	"fmt.Printf":           0,
+	"fmt.Sprintf":          0,
+	"log.Fatalf":           0,
+	"log.Panicf":           0,
+	"log.Printf":           0,
+	"(*log.Logger).Fatalf": 0,
+	"(*log.Logger).Panicf": 0,
+	"(*log.Logger).Printf": 0,
+}
+
+// FormatVerbMutator mutates the verbs of the format string of printf like
+// functions. Each verb is changed to %v, unless that's the default format of
+// the argument, and dropped along with its argument.
+//	fmt.Sprintf("%d-%s", a, b) to fmt.Sprintf("%v-%s", a, b)
+//	fmt.Sprintf("%d-%s", a, b) to fmt.Sprintf("-%s", b)
+func FormatVerbMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	call, ok := node.(*ast.CallExpr)
+	if !ok || call.Ellipsis.IsValid() {
+		return
+	}
+	idx, ok := formatFuncs[funcName(parseInfo, call)]
+	if !ok || idx >= len(call.Args) {
+		return
+	}
+	lit, ok := call.Args[idx].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return
+	}
+	format, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return
+	}
+	verbs, ok := parseVerbs(format)
+	if !ok {
+		return
+	}
+	args := call.Args[idx+1:]
+
+	oldValue, oldArgs := lit.Value, call.Args
+	// the format is changed in place, orig holds a copy of it.
+	origLit := *lit
+	orig := *call
+	orig.Args = append([]ast.Expr(nil), oldArgs...)
+	orig.Args[idx] = &origLit
+	for i, v := range verbs {
+		// change to %v
+		if v.verb != 'v' && (i >= len(args) || !isDefaultVerb(parseInfo, args[i], v.verb)) {
+			lit.Value = strconv.Quote(format[:v.end-1] + "v" + format[v.end:])
+
+			testMutant(parseInfo, &orig, call, tester)
+
+			lit.Value = oldValue
+		}
+
+		// drop the verb and its argument
+		lit.Value = strconv.Quote(format[:v.start] + format[v.end:])
+		if i < len(args) {
+			mutation := make([]ast.Expr, 0, len(oldArgs)-1)
+			mutation = append(mutation, oldArgs[:idx+1+i]...)
+			mutation = append(mutation, oldArgs[idx+2+i:]...)
+			call.Args = mutation
+		}
+
+		testMutant(parseInfo, &orig, call, tester)
+
+		lit.Value, call.Args = oldValue, oldArgs
+	}
+}
+
+// formatVerb is a verb of a format string, format[start:end] is the whole verb
+// including flags, width and precision.
+type formatVerb struct {
+	start, end int
+	verb       byte
+}
+
+// parseVerbs returns the verbs of a printf format string, the i-th verb
+// formats the i-th argument. It returns false if the format uses explicit
+// argument indexes or * width and precision since verbs and arguments don't
+// match one to one anymore.
+func parseVerbs(format string) ([]formatVerb, bool) {
+	var verbs []formatVerb
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		j := i + 1
+		for j < len(format) && strings.IndexByte("+-# 0123456789.", format[j]) >= 0 {
+			j++
+		}
+		if j == len(format) {
+			break
+		}
+		switch c := format[j]; {
+		case c == '%':
+		case c == '[' || c == '*':
+			return nil, false
+		case c < utf8.RuneSelf:
+			verbs = append(verbs, formatVerb{start: i, end: j + 1, verb: c})
+		default:
+			return nil, false
+		}
+		i = j
+	}
+	return verbs, true
+}
+
+// isDefaultVerb returns true if verb formats the argument like %v does.
+func isDefaultVerb(parseInfo ParseInfo, arg ast.Expr, verb byte) bool {
+	t, ok := parseInfo.TypesInfo.Types[arg]
+	if !ok {
+		return false
+	}
+	// named types might implement fmt.Stringer.
+	b, ok := t.Type.(*types.Basic)
+	if !ok {
+		return false
+	}
+	info := b.Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		return verb == 't'
+	case info&types.IsInteger != 0:
+		return verb == 'd'
+	case info&(types.IsFloat|types.IsComplex) != 0:
+		return verb == 'g'
+	case info&types.IsString != 0:
+		return verb == 's'
+	}
+	return false
+}
+
+// CallReplacement describes how CallReplacementMutator rewrites a call.
+type CallReplacement struct {
+	// Name is the name of the function replacing the original, it must be in
+	// the same package, or method set, as the original.
+	Name string
+	// Negate negates the result of the call instead of renaming it.
+	Negate bool
+	// InvertLess swaps the parameters of the function literal passed as the
+	// last argument, inverting comparators like the one of sort.Slice.
+	InvertLess bool
+}
+
+// CallReplacements maps the full name of functions and methods, eg.
+// "strings.HasPrefix" or "(time.Time).Before", to their replacement. Entries
+// can be added to mutate calls to other packages.
+var CallReplacements = map[string]CallReplacement{
+	"bytes.Equal":        {Negate: true},
+	"bytes.HasPrefix":    {Name: "HasSuffix"},
+	"bytes.HasSuffix":    {Name: "HasPrefix"},
+	"bytes.Index":        {Name: "LastIndex"},
+	"bytes.LastIndex":    {Name: "Index"},
+	"bytes.TrimLeft":     {Name: "TrimRight"},
+	"bytes.TrimPrefix":   {Name: "TrimSuffix"},
+	"bytes.TrimRight":    {Name: "TrimLeft"},
+	"bytes.TrimSuffix":   {Name: "TrimPrefix"},
+	"math.Ceil":          {Name: "Floor"},
+	"math.Floor":         {Name: "Ceil"},
+	"math.Max":           {Name: "Min"},
+	"math.Min":           {Name: "Max"},
+	"reflect.DeepEqual":  {Negate: true},
+	"sort.Slice":         {InvertLess: true},
+	"sort.SliceStable":   {InvertLess: true},
+	"strings.EqualFold":  {Negate: true},
+	"strings.HasPrefix":  {Name: "HasSuffix"},
+	"strings.HasSuffix":  {Name: "HasPrefix"},
+	"strings.Index":      {Name: "LastIndex"},
+	"strings.LastIndex":  {Name: "Index"},
+	"strings.TrimLeft":   {Name: "TrimRight"},
+	"strings.TrimPrefix": {Name: "TrimSuffix"},
+	"strings.TrimRight":  {Name: "TrimLeft"},
+	"strings.TrimSuffix": {Name: "TrimPrefix"},
+	"(time.Time).After":  {Name: "Before"},
+	"(time.Time).Before": {Name: "After"},
+}
+
+// CallReplacementMutator replaces calls to well known functions with close
+// relatives as described by CallReplacements.
+//
+//	strings.HasPrefix(s, p)     to strings.HasSuffix(s, p)
+//	bytes.Equal(a, b)           to !bytes.Equal(a, b)
+//	sort.Slice(s, func(i, j int) to sort.Slice(s, func(j, i int)
+func CallReplacementMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	if call, ok := node.(*ast.CallExpr); ok {
+		repl := CallReplacements[funcName(parseInfo, call)]
+		if repl.Name != "" {
+			renameCall(parseInfo, call, repl.Name, tester)
+		}
+		if repl.InvertLess {
+			invertLess(parseInfo, call, tester)
+		}
+	}
+
+	// negating an expression requires to replace it in its parent, unless
+	// it's already negated or its result is dropped, a negated expression
+	// isn't a valid statement.
+	if unary, ok := node.(*ast.UnaryExpr); ok && unary.Op == token.NOT {
+		return
+	}
+	if _, ok := node.(*ast.ExprStmt); ok {
+		return
+	}
+	for _, expr := range childExprs(node) {
+		call, ok := (*expr).(*ast.CallExpr)
+		if !ok || !CallReplacements[funcName(parseInfo, call)].Negate {
+			continue
+		}
+
+		mutant := &ast.UnaryExpr{OpPos: call.Pos(), Op: token.NOT, X: call}
+		*expr = mutant
+
+		testMutant(parseInfo, call, mutant, tester)
+
+		*expr = call
+	}
+}
+
+// renameCall changes the name of the function called by call.
+func renameCall(parseInfo ParseInfo, call *ast.CallExpr, name string, tester Tester) {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return
+	}
+
+	orig := *call
+	old := ident.Name
+	ident.Name = name
+
+	testMutant(parseInfo, &orig, call, tester)
+
+	ident.Name = old
+}
+
+// invertLess swaps the two parameters of a function literal passed as the last
+// argument of call.
+func invertLess(parseInfo ParseInfo, call *ast.CallExpr, tester Tester) {
+	if len(call.Args) == 0 {
+		return
+	}
+	lit, ok := call.Args[len(call.Args)-1].(*ast.FuncLit)
+	if !ok {
+		return
+	}
+
+	var params []*ast.Ident
+	for _, field := range lit.Type.Params.List {
+		params = append(params, field.Names...)
+	}
+	if len(params) != 2 || params[0].Name == "_" || params[1].Name == "_" {
+		return
+	}
+
+	orig := *call
+	a, b := params[0], params[1]
+	a.Name, b.Name = b.Name, a.Name
+
+	testMutant(parseInfo, &orig, call, tester)
+
+	a.Name, b.Name = b.Name, a.Name
+}
+
+// DeferRemoverMutator removes defer statements. The deferred calls to close and
+// to the methods of sync.WaitGroup are already removed by CloseRemoverMutator
+// and WaitGroupMutator.
+func DeferRemoverMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	stmts := stmtList(node)
+	if stmts == nil {
+		return
+	}
+
+	for i, stmt := range *stmts {
+		defstmt, ok := stmt.(*ast.DeferStmt)
+		if !ok || !covered(parseInfo, stmt) {
+			continue
+		}
+		switch funcName(parseInfo, defstmt.Call) {
+		case "(*sync.WaitGroup).Add", "(*sync.WaitGroup).Done":
+			continue
+		}
+		if isBuiltin(parseInfo, defstmt.Call, "close") {
+			continue
+		}
+		replaceStmt(stmts, i, nil, tester)
+	}
+}
+
+// DeferImmediateMutator runs deferred calls immediately.
+//
+//	defer f() to f()
+func DeferImmediateMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	stmts := stmtList(node)
+	if stmts == nil {
+		return
+	}
+
+	for i, stmt := range *stmts {
+		defstmt, ok := stmt.(*ast.DeferStmt)
+		if !ok || !covered(parseInfo, stmt) {
+			continue
+		}
+		replaceStmt(stmts, i, []ast.Stmt{&ast.ExprStmt{X: defstmt.Call}}, tester)
+	}
+}
+
+// RecoverRemoverMutator removes the calls to recover made by deferred function
+// literals, letting the panic go through.
+//
+//	recover()      to nothing
+//	r := recover() to r := interface{}(nil)
+func RecoverRemoverMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	defstmt, ok := node.(*ast.DeferStmt)
+	if !ok {
+		return
+	}
+	lit, ok := defstmt.Call.Fun.(*ast.FuncLit)
+	if !ok {
+		return
+	}
+
+	isRecover := func(expr ast.Expr) bool {
+		call, ok := expr.(*ast.CallExpr)
+		return ok && isBuiltin(parseInfo, call, "recover")
+	}
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		// recover only stops panics when called directly by the deferred
+		// function.
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+
+		if stmts := stmtList(n); stmts != nil {
+			for i, stmt := range *stmts {
+				if expr, ok := stmt.(*ast.ExprStmt); ok && isRecover(expr.X) {
+					replaceStmt(stmts, i, nil, tester)
+				}
+			}
+		}
+
+		if _, ok := n.(*ast.ExprStmt); ok {
+			return true
+		}
+		for _, expr := range childExprs(n) {
+			if !isRecover(*expr) {
+				continue
+			}
+			old := *expr
+			// the positions keep the empty interface on one line.
+			pos := old.Pos()
+			*expr = &ast.CallExpr{
+				Fun:  &ast.ParenExpr{X: &ast.InterfaceType{Interface: pos, Methods: &ast.FieldList{Opening: pos, Closing: pos}}},
+				Args: []ast.Expr{&ast.Ident{Name: "nil"}},
+			}
+
+			tester.Test()
+
+			*expr = old
+		}
+		return true
+	})
+}
+
+// CompositeLitMutator removes the fields of struct literals one at a time and
+// the elements of slice and map literals. Fields of unkeyed struct literals and
+// array elements are replaced with their zero value instead. The literals of
+// package level variables have no coverage, they are always evaluated.
+//
+//	T{A: a, B: b}   to T{B: b}
+//	T{a, b}         to T{0, b}
+//	[]int{1, 2, 3}  to []int{2, 3}
+func CompositeLitMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) && !initializesPackage(parseInfo, node) {
+		return
+	}
+
+	lit, ok := node.(*ast.CompositeLit)
+	if !ok {
+		return
+	}
+	t := parseInfo.TypesInfo.TypeOf(lit)
+	if t == nil {
+		return
+	}
+	// the type of elided &T{} literals is *T.
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		for i, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if !isZeroValue(parseInfo, kv.Value) {
+					removeElt(parseInfo, lit, i, tester)
+				}
+				continue
+			}
+			if i < u.NumFields() {
+				zeroElt(parseInfo, lit, i, u.Field(i).Type(), tester)
+			}
+		}
+	case *types.Array:
+		for i := range lit.Elts {
+			zeroElt(parseInfo, lit, i, u.Elem(), tester)
+		}
+	case *types.Slice, *types.Map:
+		for i := range lit.Elts {
+			removeElt(parseInfo, lit, i, tester)
+		}
+	}
+}
+
+// initializesPackage returns true if node is part of the value of a package
+// level variable evaluated when the package is initialized, outside of
+// function literals.
+func initializesPackage(parseInfo ParseInfo, node ast.Node) bool {
+	if parseInfo.File == nil {
+		return false
+	}
+	path, _ := astutil.PathEnclosingInterval(parseInfo.File, node.Pos(), node.End())
+	for i, n := range path {
+		switch n := n.(type) {
+		case *ast.FuncLit, *ast.FuncDecl:
+			return false
+		case *ast.GenDecl:
+			// the declarations of the package are the children of the file.
+			_, ok := path[i+1].(*ast.File)
+			return ok && n.Tok == token.VAR
+		}
+	}
+	return false
+}
+
+// removeElt removes the i-th element of lit, tests the mutant and restores lit.
+func removeElt(parseInfo ParseInfo, lit *ast.CompositeLit, i int, tester Tester) {
+	orig := *lit
+
+	mutation := make([]ast.Expr, 0, len(orig.Elts)-1)
+	mutation = append(mutation, orig.Elts[:i]...)
+	mutation = append(mutation, orig.Elts[i+1:]...)
+	lit.Elts = mutation
+
+	testMutant(parseInfo, &orig, lit, tester)
+
+	lit.Elts = orig.Elts
+}
+
+// zeroElt replaces the value of the i-th element of lit with the zero value of
+// t, tests the mutant and restores lit. Elements already equal to the zero
+// value are left alone.
+func zeroElt(parseInfo ParseInfo, lit *ast.CompositeLit, i int, t types.Type, tester Tester) {
+	value := &lit.Elts[i]
+	if kv, ok := (*value).(*ast.KeyValueExpr); ok {
+		value = &kv.Value
+	}
+	if isZeroValue(parseInfo, *value) {
+		return
+	}
+	zero := zeroValue(parseInfo, t, (*value).Pos())
+	if zero == nil {
+		return
+	}
+
+	old := *value
+	*value = zero
+
+	testMutant(parseInfo, old, zero, tester)
+
+	*value = old
+}
+
+// MapWriteRemoverMutator removes writes to maps.
+//
+//	m[k] = v to nothing
+func MapWriteRemoverMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	stmts := stmtList(node)
+	if stmts == nil {
+		return
+	}
+
+	for i, stmt := range *stmts {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || assign.Tok == token.DEFINE || !covered(parseInfo, stmt) {
+			continue
+		}
+		if !isMapWrite(parseInfo, assign) || dropsLastUse(parseInfo, stmt) {
+			continue
+		}
+		replaceStmt(stmts, i, nil, tester)
+	}
+}
+
+// isMapWrite returns true if all the left hand sides of assign are map index
+// expressions.
+func isMapWrite(parseInfo ParseInfo, assign *ast.AssignStmt) bool {
+	for _, lhs := range assign.Lhs {
+		index, ok := lhs.(*ast.IndexExpr)
+		if !ok {
+			return false
+		}
+		t := parseInfo.TypesInfo.TypeOf(index.X)
+		if t == nil {
+			return false
+		}
+		if _, ok := t.Underlying().(*types.Map); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// dropsLastUse returns true if node holds every use of a local variable, in
+// which case removing node would not compile.
+func dropsLastUse(parseInfo ParseInfo, node ast.Node) bool {
+	for obj, n := range LocalUses(parseInfo.TypesInfo, node) {
+		if countUses(parseInfo, obj) == n {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignZeroMutator replaces the assigned values with the zero value of their
+// type.
+//
+//	x = y to x = 0
+func AssignZeroMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	assign, ok := node.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != len(assign.Rhs) {
+		return
+	}
+
+	for i, rhs := range assign.Rhs {
+		if isZeroValue(parseInfo, rhs) || dropsLastUse(parseInfo, rhs) {
+			continue
+		}
+		t := parseInfo.TypesInfo.TypeOf(assign.Lhs[i])
+		if t == nil {
+			// blank identifier.
+			continue
+		}
+		zero := zeroValue(parseInfo, t, rhs.Pos())
+		if zero == nil {
+			continue
+		}
+
+		assign.Rhs[i] = zero
+
+		testMutant(parseInfo, rhs, zero, tester)
+
+		assign.Rhs[i] = rhs
+	}
+}
+
+// TupleSwapMutator swaps the variables receiving the results of a call when
+// they have the same type.
+//
+//	a, b := f() to b, a := f()
+func TupleSwapMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	assign, ok := node.(*ast.AssignStmt)
+	if !ok || len(assign.Rhs) != 1 || len(assign.Lhs) < 2 {
+		return
+	}
+	if assign.Tok != token.DEFINE && assign.Tok != token.ASSIGN {
+		return
+	}
+
+	for i := 0; i < len(assign.Lhs); i++ {
+		for j := i + 1; j < len(assign.Lhs); j++ {
+			a, b := assign.Lhs[i], assign.Lhs[j]
+			if isBlank(a) || isBlank(b) {
+				continue
+			}
+			ta, tb := parseInfo.TypesInfo.TypeOf(a), parseInfo.TypesInfo.TypeOf(b)
+			if ta == nil || tb == nil || !types.Identical(ta, tb) {
+				continue
+			}
+
+			assign.Lhs[i], assign.Lhs[j] = b, a
+
+			tester.Test()
+
+			assign.Lhs[i], assign.Lhs[j] = a, b
+		}
+	}
+}
+
+// isBlank returns true if expr is the blank identifier.
+func isBlank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
+
+// CommaOkMutator drops the ok check of comma-ok expressions, type assertions
+// panic instead of failing.
+//
+//	v, ok := m[k] to v, ok := m[k], true
+func CommaOkMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	assign, ok := node.(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 2 || len(assign.Rhs) != 1 || isBlank(assign.Lhs[1]) {
+		return
+	}
+
+	switch rhs := assign.Rhs[0].(type) {
+	case *ast.IndexExpr:
+		t := parseInfo.TypesInfo.TypeOf(rhs.X)
+		if t == nil {
+			return
+		}
+		if _, ok := t.Underlying().(*types.Map); !ok {
+			return
+		}
+	case *ast.TypeAssertExpr:
+	case *ast.UnaryExpr:
+		if rhs.Op != token.ARROW {
+			return
+		}
+	default:
+		return
+	}
+
+	orig := *assign
+	assign.Rhs = []ast.Expr{orig.Rhs[0], &ast.Ident{Name: "true"}}
+
+	testMutant(parseInfo, &orig, assign, tester)
+
+	assign.Rhs = orig.Rhs
+}
+
+// DebugInspect is a dev mutator used to inspect the ast.Node hierarchy of the
+// ast tree.
+func DebugInspect(parseInfo ParseInfo, node ast.Node, tester Tester) {
+
+}
+
+// ReturnValueMutator changes various return value. (eg. numbers become zero)
+func ReturnValueMutator(parseInfo ParseInfo, node ast.Node, tester Tester) {
+	if !covered(parseInfo, node) {
+		return
+	}
+
+	if block, ok := node.(*ast.BlockStmt); ok {
+		returnValueMutator(&block.List, parseInfo, tester)
+	}
+
+	// case bodies are not considered BlockStmt.
+	if casec, ok := node.(*ast.CaseClause); ok {
+		returnValueMutator(&casec.Body, parseInfo, tester)
+	}
+}
+
+func returnValueMutator(stmts *[]ast.Stmt, parseInfo ParseInfo, tester Tester) {
+	for i, stmt := range *stmts {
+		ret, ok := stmt.(*ast.ReturnStmt)
+		if !ok {
+			continue
+		}
+		for _, expr := range ret.Results {
+			switch e := expr.(type) {
+			case *ast.BasicLit:
+				switch e.Kind {
+				case token.INT, token.FLOAT:
+					repl := "0"
+					if zeroRegexp.Match([]byte(e.Value)) {
+						repl = "1"
+					}
+
+					old := e.Value
+					e.Value = repl
+
+					tester.Test()
+
+					e.Value = old
+				}
+			case *ast.Ident:
+				switch t := parseInfo.TypesInfo.Types[expr].Type.(type) {
+				case *types.Basic:
+					unusedAssign := &ast.AssignStmt{
+						Lhs: []ast.Expr{&ast.Ident{Name: "_"}},
+						Rhs: []ast.Expr{&ast.Ident{Name: e.Name}},
+						Tok: token.ASSIGN, // assignment token, DEFINE
+						//TokPos: token.Pos,   // position of Tok
+					}
+					old := *stmts
+					nw := make([]ast.Stmt, len(*stmts))
+					copy(nw, old)
+
+					nw = append(nw, nil)
+					copy(nw[i+1:], nw[i:])
+					nw[i] = unusedAssign
+
+					*stmts = nw
+
+					tester.Test()
+
+					*stmts = old
+				case *types.Pointer:
+					fmt.Println(t)
+				case *types.Named:
+					fmt.Println(t)
+				default:
+					fmt.Printf("unknown ident type %T\n", parseInfo.TypesInfo.Types[expr].Type)
+				}
+			default:
+				fmt.Printf("unknown expr type %T\n", expr)
+			}
+		}
+	}
+}
+
+var floatComparisonInverterMap = map[token.Token]token.Token{
+	token.EQL: token.NEQ,
+	token.NEQ: token.EQL,
 
 	token.LSS: token.GEQ,
 	token.GEQ: token.LSS,
@@ -743,6 +3420,54 @@ func isString(parseInfo ParseInfo, expr ast.Expr) bool {
 	return b.Kind() == types.String || b.Kind() == types.UntypedString
 }
 
+var errorType = types.Universe.Lookup("error").Type()
+
+// isError returns true if the expression is of type error.
+func isError(parseInfo ParseInfo, expr ast.Expr) bool {
+	t, ok := parseInfo.TypesInfo.Types[expr]
+	if !ok {
+		return false
+	}
+
+	return types.Identical(t.Type, errorType)
+}
+
+// isNil returns true if the expression is the predeclared nil.
+func isNil(parseInfo ParseInfo, expr ast.Expr) bool {
+	t, ok := parseInfo.TypesInfo.Types[expr]
+	if !ok {
+		return false
+	}
+
+	return t.IsNil()
+}
+
+// isInteger returns true if the expression is of an integer type.
+func isInteger(parseInfo ParseInfo, expr ast.Expr) bool {
+	t, ok := parseInfo.TypesInfo.Types[expr]
+	if !ok {
+		return false
+	}
+
+	b, ok := t.Type.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsInteger != 0
+}
+
+// isNilable returns true if the expression is of a type that can be compared
+// to nil, eg. pointers, maps, slices, interfaces, functions and channels.
+func isNilable(parseInfo ParseInfo, expr ast.Expr) bool {
+	t, ok := parseInfo.TypesInfo.Types[expr]
+	if !ok || t.IsNil() {
+		return false
+	}
+
+	switch t.Type.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Slice, *types.Interface, *types.Signature, *types.Chan:
+		return true
+	}
+	return false
+}
+
 // printPos is a debug function that allows me to quickly see the position of a
 // specific statement.
 func printPos(parseInfo ParseInfo, n ast.Node) {
@@ -0,0 +1,649 @@
+package godzilla
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mutantPrinter prints the lines changed by each mutant.
+type mutantPrinter struct {
+	parseInfo ParseInfo
+	// the source of the declarations before the mutations.
+	decls   []string
+	mutants []string
+}
+
+func (p *mutantPrinter) Test() {
+	var changes []string
+	for i, decl := range p.parseInfo.File.Decls {
+		var b bytes.Buffer
+		format.Node(&b, p.parseInfo.FileSet, decl)
+		if b.String() != p.decls[i] {
+			changes = append(changes, diffLines(p.decls[i], b.String()))
+		}
+	}
+	p.mutants = append(p.mutants, strings.Join(changes, "\n"))
+}
+
+// diffLines returns the lines of orig replaced in mutant as
+// "orig lines => mutant lines", lines are trimmed and joined with "; ". Empty
+// lines are left out, moved nodes can be printed with extra line breaks.
+func diffLines(orig, mutant string) string {
+	o, m := strings.Split(orig, "\n"), strings.Split(mutant, "\n")
+	for len(o) > 0 && len(m) > 0 && o[0] == m[0] {
+		o, m = o[1:], m[1:]
+	}
+	for len(o) > 0 && len(m) > 0 && o[len(o)-1] == m[len(m)-1] {
+		o, m = o[:len(o)-1], m[:len(m)-1]
+	}
+	trim := func(lines []string) string {
+		var trimmed []string
+		for _, line := range lines {
+			if line = strings.TrimSpace(line); line != "" {
+				trimmed = append(trimmed, line)
+			}
+		}
+		return strings.Join(trimmed, "; ")
+	}
+	return strings.TrimSpace(trim(o) + " => " + trim(m))
+}
+
+// runMutator runs the mutator on every node of the file with no filter and
+// returns the lines changed by each mutant.
+func runMutator(mutator string, parseInfo ParseInfo) []string {
+	defer func(filters map[string]Filter) { Filters = filters }(Filters)
+	Filters = nil
+
+	p := &mutantPrinter{parseInfo: parseInfo}
+	for _, decl := range parseInfo.File.Decls {
+		var b bytes.Buffer
+		format.Node(&b, parseInfo.FileSet, decl)
+		p.decls = append(p.decls, b.String())
+	}
+	m := Mutators[mutator].M
+	ast.Inspect(parseInfo.File, func(n ast.Node) bool {
+		if n != nil {
+			m(parseInfo, n, p)
+		}
+		return true
+	})
+	return p.mutants
+}
+
+// checkMutants runs the mutator on src and compares the mutants with want.
+func checkMutants(t *testing.T, mutator, src string, want []string) {
+	t.Helper()
+	got := runMutator(mutator, parseSource(t, src))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s mutants:\n%s\nwant:\n%s", mutator, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParseVerbs(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		verbs  []formatVerb
+		ok     bool
+	}{
+		{"no verb", nil, true},
+		{"%d-%s", []formatVerb{{0, 2, 'd'}, {3, 5, 's'}}, true},
+		{"100%% %v", []formatVerb{{6, 8, 'v'}}, true},
+		{"%-08.3f|%+q", []formatVerb{{0, 7, 'f'}, {8, 11, 'q'}}, true},
+		{"trailing %", nil, true},
+		{"%[1]d", nil, false},
+		{"%*d", nil, false},
+		{"%.*f", nil, false},
+	} {
+		verbs, ok := parseVerbs(tc.format)
+		if !reflect.DeepEqual(verbs, tc.verbs) || ok != tc.ok {
+			t.Errorf("parseVerbs(%q) = %v, %v, want %v, %v", tc.format, verbs, ok, tc.verbs, tc.ok)
+		}
+	}
+}
+
+func TestMutators(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		mutator string
+		src     string
+		mutants []string
+	}{
+		{
+			name:    "if condition",
+			mutator: "condconst",
+			src:     "package a\nfunc f(a int) int {\n\tif a > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}",
+			mutants: []string{
+				"if a > 0 { => if true {",
+				"if a > 0 { => if false {",
+			},
+		},
+		{
+			name:    "init only used in the condition",
+			mutator: "condconst",
+			src:     "package a\nfunc f(a []int) int {\n\tif n := len(a); n > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}",
+		},
+		{
+			name:    "init used in the body",
+			mutator: "condconst",
+			src:     "package a\nfunc f(a []int) int {\n\tif n := len(a); n > 0 {\n\t\treturn n\n\t}\n\treturn 0\n}",
+			mutants: []string{
+				"if n := len(a); n > 0 { => if n := len(a); true {",
+				"if n := len(a); n > 0 { => if n := len(a); false {",
+			},
+		},
+		{
+			name:    "loop without exit",
+			mutator: "condconst",
+			src:     "package a\nfunc f(n int) (s int) {\n\tfor i := 0; i < n; i++ {\n\t\ts += i\n\t}\n\treturn\n}",
+			mutants: []string{"for i := 0; i < n; i++ { => for i := 0; false; i++ {"},
+		},
+		{
+			name:    "loop with break",
+			mutator: "condconst",
+			src:     "package a\nfunc f(n int) (s int) {\n\tfor s < n {\n\t\tif s > 10 {\n\t\t\tbreak\n\t\t}\n\t\ts++\n\t}\n\treturn\n}",
+			mutants: []string{
+				"for s < n { => for true {",
+				"for s < n { => for false {",
+				"if s > 10 { => if true {",
+				"if s > 10 { => if false {",
+			},
+		},
+		{
+			name:    "operands of &&",
+			mutator: "condconst",
+			src:     "package a\nfunc f(a, b bool) bool {\n\treturn a && b\n}",
+			mutants: []string{
+				"return a && b => return true && b",
+				"return a && b => return false && b",
+				"return a && b => return a && true",
+				"return a && b => return a && false",
+			},
+		},
+		{
+			name:    "error check",
+			mutator: "errcheckrm",
+			src:     "package a\nfunc g() error { return nil }\nfunc f() error {\n\tif err := g(); err != nil {\n\t\treturn err\n\t}\n\treturn nil\n}",
+			mutants: []string{"return err =>"},
+		},
+		{
+			name:    "error check without return",
+			mutator: "errcheckrm",
+			src:     "package a\nfunc g() error { return nil }\nfunc f() {\n\tif err := g(); err != nil {\n\t\tpanic(err)\n\t}\n}",
+		},
+		{
+			name:    "returned error",
+			mutator: "errnil",
+			src:     "package a\nfunc g() error { return nil }\nfunc f() (int, error) {\n\terr := g()\n\treturn 0, err\n}",
+			mutants: []string{"return 0, err => return 0, nil"},
+		},
+		{
+			name:    "returned nil",
+			mutator: "errnil",
+			src:     "package a\nfunc f() error {\n\treturn nil\n}",
+		},
+		{
+			name:    "wrapped error",
+			mutator: "errunwrap",
+			src:     "package a\nimport \"fmt\"\nfunc f(err error) error {\n\treturn fmt.Errorf(\"f %d: %w\", 1, err)\n}",
+			mutants: []string{"return fmt.Errorf(\"f %d: %w\", 1, err) => return error(err)"},
+		},
+		{
+			name:    "formatted error",
+			mutator: "errunwrap",
+			src:     "package a\nimport \"fmt\"\nfunc f() error {\n\treturn fmt.Errorf(\"f %d\", 1)\n}",
+		},
+		{
+			name:    "nil check",
+			mutator: "nilcheck",
+			src:     "package a\nfunc f(p *int) int {\n\tif p != nil {\n\t\treturn *p\n\t}\n\treturn 0\n}",
+			mutants: []string{
+				"return *p =>",
+				"if p != nil { => if true {",
+			},
+		},
+		{
+			name:    "nil check with else",
+			mutator: "nilcheck",
+			src:     "package a\nfunc f(m map[int]int) (n int) {\n\tif m == nil {\n\t\tn = 1\n\t} else {\n\t\tn = 2\n\t}\n\treturn\n}",
+			mutants: []string{
+				"n = 1 =>",
+				"if m == nil { => if true {",
+				"if m == nil { => if false {",
+			},
+		},
+		{
+			name:    "nil error check",
+			mutator: "nilcheck",
+			src:     "package a\nfunc f(err error) int {\n\tif err != nil {\n\t\treturn 1\n\t}\n\treturn 0\n}",
+		},
+		{
+			name:    "error check left to errcheckrm",
+			mutator: "condconst",
+			src:     "package a\nfunc f(err error) int {\n\tif err != nil {\n\t\treturn 1\n\t}\n\treturn 0\n}",
+			mutants: []string{
+				"if err != nil { => if true {",
+				"if err != nil { => if false {",
+			},
+		},
+		{
+			name:    "nil check left to nilcheck",
+			mutator: "condconst",
+			src:     "package a\nfunc f(p *int) int {\n\tif p != nil {\n\t\treturn *p\n\t}\n\treturn 0\n}",
+		},
+		{
+			name:    "deferred unlock",
+			mutator: "lockrm",
+			src:     "package a\nimport \"sync\"\nfunc f(mu *sync.Mutex, n *int) {\n\tmu.Lock()\n\tdefer mu.Unlock()\n\t*n++\n}",
+			mutants: []string{"mu.Lock(); defer mu.Unlock() =>"},
+		},
+		{
+			name:    "read lock",
+			mutator: "rlock",
+			src:     "package a\nimport \"sync\"\nfunc f(mu *sync.RWMutex, n *int) int {\n\tmu.RLock()\n\tv := *n\n\tmu.RUnlock()\n\treturn v\n}",
+			mutants: []string{"mu.RLock(); v := *n; mu.RUnlock() => mu.Lock(); v := *n; mu.Unlock()"},
+		},
+		{
+			name:    "wait group",
+			mutator: "wgrm",
+			src:     "package a\nimport \"sync\"\nfunc f(wg *sync.WaitGroup) {\n\twg.Add(1)\n\tgo func() {\n\t\twg.Done()\n\t}()\n\twg.Wait()\n}",
+			mutants: []string{
+				"wg.Add(1) =>",
+				"wg.Done() =>",
+			},
+		},
+		{
+			name:    "go statement",
+			mutator: "gosync",
+			src:     "package a\nfunc f(c chan int) {\n\tgo func() {\n\t\tc <- 1\n\t}()\n}",
+			mutants: []string{"go func() { => func() {"},
+		},
+		{
+			name:    "close",
+			mutator: "closerm",
+			src:     "package a\nfunc f(c chan int) {\n\tdefer close(c)\n\tc <- 1\n}",
+			mutants: []string{"defer close(c) =>"},
+		},
+		{
+			name:    "channel capacity",
+			mutator: "chancap",
+			src:     "package a\nfunc f() (chan int, chan int) {\n\treturn make(chan int), make(chan int, 2)\n}",
+			mutants: []string{
+				"return make(chan int), make(chan int, 2) => return make(chan int, 1), make(chan int, 2)",
+				"return make(chan int), make(chan int, 2) => return make(chan int), make(chan int)",
+			},
+		},
+		{
+			name:    "type switch",
+			mutator: "swaptypeswitch",
+			src:     "package a\nfunc f(v interface{}) int {\n\tswitch v.(type) {\n\tcase int:\n\t\treturn 1\n\tcase string:\n\t\treturn 2\n\t}\n\treturn 0\n}",
+			mutants: []string{"return 1; case string:; return 2 => return 2; case string:; return 1"},
+		},
+		{
+			name:    "select",
+			mutator: "swapselect",
+			src:     "package a\nfunc f(a, b chan int) int {\n\tselect {\n\tcase <-a:\n\t\treturn 1\n\tcase <-b:\n\t\treturn 2\n\t}\n}",
+			mutants: []string{"return 1; case <-b:; return 2 => return 2; case <-b:; return 1"},
+		},
+		{
+			name:    "select case",
+			mutator: "selectcaserm",
+			src:     "package a\nfunc f(a, b chan int) int {\n\tselect {\n\tcase <-a:\n\t\treturn 1\n\tcase <-b:\n\t}\n\treturn 0\n}",
+			mutants: []string{
+				"case <-a:; return 1 =>",
+				"case <-b: =>",
+			},
+		},
+		{
+			name:    "select default",
+			mutator: "selectdefaultrm",
+			src:     "package a\nfunc f(a chan int) int {\n\tselect {\n\tcase <-a:\n\t\treturn 1\n\tdefault:\n\t}\n\treturn 0\n}",
+			mutants: []string{"default: =>"},
+		},
+		{
+			name:    "break and continue",
+			mutator: "branchswap",
+			src:     "package a\nfunc f(a []int) (n int) {\n\tfor _, v := range a {\n\t\tif v < 0 {\n\t\t\tcontinue\n\t\t}\n\t\tif v > 9 {\n\t\t\tbreak\n\t\t}\n\t\tn += v\n\t}\n\treturn\n}",
+			mutants: []string{
+				"continue => break",
+				"break => continue",
+			},
+		},
+		{
+			name:    "break inside switch",
+			mutator: "branchswap",
+			src:     "package a\nfunc f(a []int) (n int) {\n\tfor _, v := range a {\n\t\tswitch v {\n\t\tcase 0:\n\t\t\tbreak\n\t\tcase 1:\n\t\t\tcontinue\n\t\t}\n\t\tn += v\n\t}\n\treturn\n}",
+			mutants: []string{"continue => break"},
+		},
+		{
+			name:    "branch removal",
+			mutator: "branchrm",
+			src:     "package a\nfunc f(a []int) (n int) {\n\tfor _, v := range a {\n\t\tswitch v {\n\t\tcase 0:\n\t\t\tbreak\n\t\tcase 1:\n\t\t\tcontinue\n\t\t}\n\t\tn += v\n\t}\n\treturn\n}",
+			mutants: []string{"continue =>"},
+		},
+		{
+			name:    "for loop",
+			mutator: "loopskip",
+			src:     "package a\nfunc f(n int) (s int) {\n\tfor i := 0; i < n; i++ {\n\t\ts += i\n\t}\n\treturn\n}",
+			mutants: []string{
+				"for i := 0; i < n; i++ { => for i := 0 + 1; i < n; i++ {",
+				"for i := 0; i < n; i++ { => for i := 0; i < n-1; i++ {",
+			},
+		},
+		{
+			name:    "range loop",
+			mutator: "loopskip",
+			src:     "package a\nfunc f(a []int) (s int) {\n\tfor i, v := range a {\n\t\ts += i * v\n\t}\n\treturn\n}",
+			mutants: []string{
+				"=> if true {; continue; }",
+				"=> if i == 0 {; continue; }",
+				"=> if i == len(a)-1 {; continue; }",
+			},
+		},
+		{
+			name:    "slice bounds",
+			mutator: "slicebound",
+			src:     "package a\nfunc f(s []int, i, j int) []int {\n\treturn s[i:j]\n}",
+			mutants: []string{
+				"return s[i:j] => return s[i+1 : j]",
+				"return s[i:j] => return s[i-1 : j]",
+				"return s[i:j] => return s[i : j+1]",
+				"return s[i:j] => return s[i : j-1]",
+				"return s[i:j] => return s[:j]",
+				"return s[i:j] => return s[i:]",
+			},
+		},
+		{
+			name:    "constant slice bounds",
+			mutator: "slicebound",
+			src:     "package a\nfunc f(s [4]int) []int {\n\treturn s[0:4]\n}",
+			mutants: []string{
+				"return s[0:4] => return s[0+1 : 4]",
+				"return s[0:4] => return s[0 : 4-1]",
+				"return s[0:4] => return s[0:]",
+			},
+		},
+		{
+			name:    "index",
+			mutator: "indexbound",
+			src:     "package a\nfunc f(s string, i int) byte {\n\treturn s[i]\n}",
+			mutants: []string{
+				"return s[i] => return s[i+1]",
+				"return s[i] => return s[i-1]",
+			},
+		},
+		{
+			name:    "constant index",
+			mutator: "indexbound",
+			src:     "package a\nfunc f(s [2]int) int {\n\treturn s[0]\n}",
+			mutants: []string{"return s[0] => return s[0+1]"},
+		},
+		{
+			name:    "map index",
+			mutator: "indexbound",
+			src:     "package a\nfunc f(m map[int]int, i int) int {\n\treturn m[i]\n}",
+		},
+		{
+			name:    "len",
+			mutator: "lenminus",
+			src:     "package a\nfunc f(s []int) int {\n\treturn len(s) * 2\n}",
+			mutants: []string{"return len(s) * 2 => return (len(s) - 1) * 2"},
+		},
+		{
+			name:    "len as a bound",
+			mutator: "lenminus",
+			src:     "package a\nfunc f(s []int) ([]int, int) {\n\treturn s[:len(s)], s[len(s)-1]\n}",
+		},
+		{
+			name:    "len of an array",
+			mutator: "lenminus",
+			src:     "package a\nfunc f(s [2]int) int {\n\treturn len(s)\n}",
+		},
+		{
+			name:    "variables",
+			mutator: "varswap",
+			src:     "package a\nfunc f(a, b int) int {\n\treturn a - b + a*b\n}",
+			mutants: []string{
+				"return a - b + a*b => return b - b + a*b",
+				"return a - b + a*b => return a - a + a*b",
+				"return a - b + a*b => return a - b + b*b",
+				"return a - b + a*b => return a - b + a*a",
+			},
+		},
+		{
+			name:    "only use",
+			mutator: "varswap",
+			src:     "package a\nfunc f(b int) int {\n\ta := 1\n\treturn a + b + b\n}",
+			mutants: []string{
+				"return a + b + b => return a + a + b",
+				"return a + b + b => return a + b + a",
+			},
+		},
+		{
+			name:    "assigned only",
+			mutator: "varswap",
+			src:     "package a\nvar c int\nfunc f(b int) {\n\ta := 1\n\ta = b\n\tc = a\n}",
+			mutants: []string{
+				"a = b => b = b",
+				"a = b => c = b",
+				"c = a => a = a",
+				"c = a => b = a",
+			},
+		},
+		{
+			name:    "different types",
+			mutator: "varswap",
+			src:     "package a\nfunc f(a int, b string) int {\n\treturn a + len(b)\n}",
+		},
+		{
+			name:    "arguments of the same type",
+			mutator: "argswap",
+			src:     "package a\nfunc g(a, b int, c string) int { return a }\nfunc f(x, y int) int {\n\treturn g(x, y, \"c\") + g(x, x, \"c\")\n}",
+			mutants: []string{
+				"return g(x, y, \"c\") + g(x, x, \"c\") => return g(y, x, \"c\") + g(x, x, \"c\")",
+			},
+		},
+		{
+			name:    "zero arguments",
+			mutator: "argzero",
+			src:     "package a\ntype T struct{ n int }\nfunc g(p *int, t T, s string, b bool) {}\nfunc f(n int) {\n\tg(&n, T{n}, \"s\", false)\n}",
+			mutants: []string{
+				"g(&n, T{n}, \"s\", false) => g(nil, T{n}, \"s\", false)",
+				"g(&n, T{n}, \"s\", false) => g(&n, T{}, \"s\", false)",
+				"g(&n, T{n}, \"s\", false) => g(&n, T{n}, \"\", false)",
+			},
+		},
+		{
+			name:    "renamed import",
+			mutator: "argzero",
+			src:     "package a\nimport (\n\t\"os\"\n\tt \"time\"\n)\nfunc f(fi os.FileInfo) error {\n\treturn os.Chtimes(\"a\", t.Time{}, fi.ModTime())\n}",
+			mutants: []string{
+				"return os.Chtimes(\"a\", t.Time{}, fi.ModTime()) => return os.Chtimes(\"\", t.Time{}, fi.ModTime())",
+				"return os.Chtimes(\"a\", t.Time{}, fi.ModTime()) => return os.Chtimes(\"a\", t.Time{}, t.Time{})",
+			},
+		},
+		{
+			name:    "type not imported",
+			mutator: "argzero",
+			src:     "package a\nimport \"os\"\nfunc f(fi os.FileInfo) error {\n\treturn os.Chtimes(\"a\", fi.ModTime(), fi.ModTime())\n}",
+			mutants: []string{
+				"return os.Chtimes(\"a\", fi.ModTime(), fi.ModTime()) => return os.Chtimes(\"\", fi.ModTime(), fi.ModTime())",
+			},
+		},
+		{
+			name:    "format argument",
+			mutator: "argzero",
+			src:     "package a\nimport \"fmt\"\nfunc f(n int) string {\n\treturn fmt.Sprintf(\"%d\", n)\n}",
+			mutants: []string{"return fmt.Sprintf(\"%d\", n) => return fmt.Sprintf(\"%d\", nil)"},
+		},
+		{
+			name:    "statements with side effects",
+			mutator: "stmtdup",
+			src:     "package a\nfunc f(s []int, c chan int) []int {\n\tn := 0\n\tn++\n\tc <- n\n\ts = append(s, n)\n\tn = 2\n\treturn s\n}",
+			mutants: []string{
+				"=> n++",
+				"=> c <- n",
+				"=> s = append(s, n)",
+			},
+		},
+		{
+			name:    "method call",
+			mutator: "stmtdup",
+			src:     "package a\nimport \"strings\"\nfunc f(b *strings.Builder) {\n\tb.WriteString(\"a\")\n\tprintln()\n}",
+			mutants: []string{"=> b.WriteString(\"a\")"},
+		},
+		{
+			name:    "string",
+			mutator: "strlit",
+			src:     "package a\nfunc f() string {\n\treturn \"Name\"\n}",
+			mutants: []string{
+				"return \"Name\" => return \"\"",
+				"return \"Name\" => return \"godzilla\"",
+				"return \"Name\" => return \"NAME\"",
+			},
+		},
+		{
+			name:    "string with %",
+			mutator: "strlit",
+			src:     "package a\nfunc f() string {\n\treturn \"100%\"\n}",
+			mutants: []string{
+				"return \"100%\" => return \"\"",
+				"return \"100%\" => return \"godzilla\"",
+			},
+		},
+		{
+			name:    "format string",
+			mutator: "strlit",
+			src:     "package a\nimport \"fmt\"\nfunc f(n int) string {\n\treturn fmt.Sprintf(\"n\", n)\n}",
+		},
+		{
+			name:    "format verbs",
+			mutator: "fmtverb",
+			src:     "package a\nimport \"fmt\"\nfunc f(n int, s string) string {\n\treturn fmt.Sprintf(\"%x-%s\", n, s)\n}",
+			mutants: []string{
+				"return fmt.Sprintf(\"%x-%s\", n, s) => return fmt.Sprintf(\"%v-%s\", n, s)",
+				"return fmt.Sprintf(\"%x-%s\", n, s) => return fmt.Sprintf(\"-%s\", s)",
+				"return fmt.Sprintf(\"%x-%s\", n, s) => return fmt.Sprintf(\"%x-\", n)",
+			},
+		},
+		{
+			name:    "renamed call",
+			mutator: "callrepl",
+			src:     "package a\nimport \"strings\"\nfunc f(s string) bool {\n\treturn strings.HasPrefix(s, \"a\")\n}",
+			mutants: []string{"return strings.HasPrefix(s, \"a\") => return strings.HasSuffix(s, \"a\")"},
+		},
+		{
+			name:    "negated call",
+			mutator: "callrepl",
+			src:     "package a\nimport \"bytes\"\nfunc f(a, b []byte) bool {\n\treturn bytes.Equal(a, b)\n}",
+			mutants: []string{"return bytes.Equal(a, b) => return !bytes.Equal(a, b)"},
+		},
+		{
+			name:    "already negated call",
+			mutator: "callrepl",
+			src:     "package a\nimport \"bytes\"\nfunc f(a, b []byte) bool {\n\treturn !bytes.Equal(a, b)\n}",
+		},
+		{
+			name:    "less function",
+			mutator: "callrepl",
+			src:     "package a\nimport \"sort\"\nfunc f(s []int) {\n\tsort.Slice(s, func(i, j int) bool { return s[i] < s[j] })\n}",
+			mutants: []string{
+				"sort.Slice(s, func(i, j int) bool { return s[i] < s[j] }) => sort.Slice(s, func(j, i int) bool { return s[i] < s[j] })",
+			},
+		},
+		{
+			name:    "deferred calls",
+			mutator: "deferrm",
+			src:     "package a\nimport \"sync\"\nfunc f(mu *sync.Mutex, wg *sync.WaitGroup, c chan int) {\n\tdefer wg.Done()\n\tdefer close(c)\n\tmu.Lock()\n\tdefer mu.Unlock()\n}",
+			mutants: []string{"defer mu.Unlock() =>"},
+		},
+		{
+			name:    "deferred call run immediately",
+			mutator: "deferimm",
+			src:     "package a\nfunc f(c chan int) {\n\tdefer close(c)\n\tc <- 1\n}",
+			mutants: []string{"defer close(c) => close(c)"},
+		},
+		{
+			name:    "recover",
+			mutator: "recoverrm",
+			src:     "package a\nfunc f() (err interface{}) {\n\tdefer func() {\n\t\terr = recover()\n\t}()\n\tdefer func() {\n\t\trecover()\n\t}()\n\tpanic(1)\n}",
+			mutants: []string{
+				"err = recover() => err = (interface{})(nil)",
+				"recover() =>",
+			},
+		},
+		{
+			name:    "struct literals",
+			mutator: "complit",
+			src:     "package a\ntype T struct {\n\tA, B int\n}\nfunc f() (T, T) {\n\treturn T{A: 1, B: 0}, T{1, 2}\n}",
+			mutants: []string{
+				"return T{A: 1, B: 0}, T{1, 2} => return T{B: 0}, T{1, 2}",
+				"return T{A: 1, B: 0}, T{1, 2} => return T{A: 1, B: 0}, T{0, 2}",
+				"return T{A: 1, B: 0}, T{1, 2} => return T{A: 1, B: 0}, T{1, 0}",
+			},
+		},
+		{
+			name:    "slice, array and map literals",
+			mutator: "complit",
+			src:     "package a\nfunc f() ([]int, [2]string, map[int]bool) {\n\treturn []int{1, 2}, [2]string{\"a\"}, map[int]bool{1: true}\n}",
+			mutants: []string{
+				"return []int{1, 2}, [2]string{\"a\"}, map[int]bool{1: true} => return []int{2}, [2]string{\"a\"}, map[int]bool{1: true}",
+				"return []int{1, 2}, [2]string{\"a\"}, map[int]bool{1: true} => return []int{1}, [2]string{\"a\"}, map[int]bool{1: true}",
+				"return []int{1, 2}, [2]string{\"a\"}, map[int]bool{1: true} => return []int{1, 2}, [2]string{\"\"}, map[int]bool{1: true}",
+				"return []int{1, 2}, [2]string{\"a\"}, map[int]bool{1: true} => return []int{1, 2}, [2]string{\"a\"}, map[int]bool{}",
+			},
+		},
+		{
+			name:    "map writes",
+			mutator: "mapwriterm",
+			src:     "package a\nfunc f(m map[string]int, k string) {\n\tv := len(k)\n\tm[k] = 1\n\tm[\"v\"] = v\n}",
+			mutants: []string{"m[k] = 1 =>"},
+		},
+		{
+			name:    "assigned values",
+			mutator: "assignzero",
+			src:     "package a\nfunc f(p *string, q *[]int, b bool) {\n\tn := 1\n\t*p = \"a\"\n\t*q = []int{n}\n\t*p, b = \"b\", false\n}",
+			mutants: []string{
+				"*p = \"a\" => *p = \"\"",
+				"*p, b = \"b\", false => *p, b = \"\", false",
+			},
+		},
+		{
+			name:    "tuple",
+			mutator: "tupleswap",
+			src:     "package a\nfunc g() (int, int, string) { return 0, 0, \"\" }\nfunc f() int {\n\ta, b, c := g()\n\treturn a - b + len(c)\n}",
+			mutants: []string{"a, b, c := g() => b, a, c := g()"},
+		},
+		{
+			name:    "comma ok",
+			mutator: "commaok",
+			src:     "package a\nfunc f(m map[int]int, v interface{}) (int, bool) {\n\tn, ok := m[1]\n\t_, ok = v.(int)\n\ts, _ := v.(string)\n\treturn n + len(s), ok\n}",
+			mutants: []string{
+				"n, ok := m[1] => n, ok := m[1], true",
+				"_, ok = v.(int) => _, ok = v.(int), true",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			checkMutants(t, tc.mutator, tc.src, tc.mutants)
+		})
+	}
+}
+
+func TestPackageLiterals(t *testing.T) {
+	// package level variables are initialized before the tests run, they are
+	// never covered.
+	parseInfo := parseSource(t, "package a\nvar a = []int{1, 2}\nvar f = func() []int {\n\treturn []int{3}\n}\nfunc g() []int {\n\treturn []int{4}\n}")
+	parseInfo.CoveredBlocks = nil
+	got := runMutator("complit", parseInfo)
+	want := []string{
+		"var a = []int{1, 2} => var a = []int{2}",
+		"var a = []int{1, 2} => var a = []int{1}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("complit mutants:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
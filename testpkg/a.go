@@ -1,5 +1,14 @@
 package testpkg
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
 const bazoo = 3.0
 
 var (
@@ -167,3 +176,216 @@ func Zoo2() {
 	n, m := Bar()
 	_, _ = n, m
 }
+
+func ErrorHandling(fail bool) (int, error) {
+	n, err := parse(fail)
+	if err != nil {
+		return 0, fmt.Errorf("handling: %v", err)
+	}
+	return n, nil
+}
+
+func parse(fail bool) (int, error) {
+	if fail {
+		return 0, errors.New("parse failed")
+	}
+	return 1, nil
+}
+
+func NilGuard(m map[string]int, p *int) int {
+	if m == nil {
+		return -1
+	}
+	if p != nil {
+		return m["a"] + *p
+	}
+	return m["a"]
+}
+
+type Counter struct {
+	mu sync.RWMutex
+	n  int
+}
+
+func (c *Counter) Incr() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+}
+
+func (c *Counter) Get() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.n
+}
+
+func Parallel(n int) int {
+	var c Counter
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Incr()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	<-done
+	return c.Get()
+}
+
+func Select(in <-chan int, quit <-chan struct{}) int {
+	select {
+	case v := <-in:
+		return v
+	case <-quit:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func Kind(v interface{}) string {
+	switch v.(type) {
+	case int:
+		return "int"
+	case string:
+		return "string"
+	}
+	return "other"
+}
+
+func Loops(s []int) (sum int) {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0 {
+			break
+		}
+		if s[i] == 0 {
+			continue
+		}
+		sum += s[i]
+	}
+	for i, v := range s {
+		sum += i * v
+	}
+	return sum
+}
+
+func Window(s []byte, i, j int) ([]byte, byte, int) {
+	var a [4]byte
+	copy(a[1:3], s[i:j])
+	return s[i:j], s[i], len(s) + len(a)
+}
+
+func Names(names []string, prefix string, raw []byte) []string {
+	var out []string
+	for _, name := range names {
+		if bytes.Equal([]byte(name), raw) {
+			continue
+		}
+		if strings.HasPrefix(name, prefix) {
+			out = append(out, strings.TrimLeft(name, "_"))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func Safe(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered: %v", r)
+		}
+	}()
+	f()
+	return nil
+}
+
+func Trace(log *[]string) {
+	*log = append(*log, "enter")
+	defer func() {
+		*log = append(*log, "exit")
+	}()
+	*log = append(*log, "body")
+}
+
+type Config struct {
+	Name    string
+	Retries int
+	Verbose bool
+	Limits  [2]int
+}
+
+var units = map[string]int{
+	"k": 1000,
+	"M": 1000000,
+}
+
+func NewConfig(name string) Config {
+	return Config{
+		Name:    name,
+		Retries: 3,
+		Verbose: false,
+		Limits:  [2]int{1, 10},
+	}
+}
+
+func Scale(n int, unit string) []int {
+	return []int{n, n * units[unit]}
+}
+
+func MinMax(s []int) (int, int) {
+	lo, hi := s[0], s[0]
+	for _, v := range s {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	return lo, hi
+}
+
+func Histogram(s []int, seen map[int]bool, in <-chan interface{}) map[int]int {
+	h := map[int]int{}
+	for _, v := range s {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		h[v]++
+	}
+	lo, hi := MinMax(s)
+	h[lo] = hi
+	if v, ok := <-in; ok {
+		if n, ok := v.(int); ok {
+			h[n] = 0
+		}
+	}
+	return h
+}
+
+const offset = 0
+
+func Steps(n int, mask uint8) (sum int, m uint8) {
+	for i := 0; i < 10; i += 3 {
+		sum += i + offset
+	}
+	m = mask | 1
+	return sum * n, mask & mask
+}
+
+const debug = false
+
+// Clamp only changes dead code, its condition mutants compile to the same
+// binary and must be reported equivalent.
+func Clamp(x int) int {
+	if x > 10 && debug {
+		return 10
+	}
+	return x
+}
@@ -14,5 +14,77 @@ func TestFoo(t *testing.T) {
 func TestBar(t *testing.T) {
 	Bar()
 }
+
+func TestErrorHandling(t *testing.T) {
+	ErrorHandling(false)
+	ErrorHandling(true)
+}
+
+func TestParallel(t *testing.T) {
+	if n := Parallel(10); n != 10 {
+		t.Errorf("Parallel(10) = %d, want 10", n)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	in := make(chan int, 1)
+	quit := make(chan struct{})
+	Select(in, quit)
+	in <- 1
+	Select(in, quit)
+	close(quit)
+	Select(in, quit)
+}
+
+func TestKind(t *testing.T) {
+	Kind(1)
+	Kind("a")
+}
+
+func TestLoops(t *testing.T) {
+	Loops([]int{1, 0, 2, -1, 3})
+}
+
+func TestWindow(t *testing.T) {
+	Window([]byte("abcdef"), 1, 3)
+}
+
+func TestNilGuard(t *testing.T) {
+	n := 1
+	NilGuard(nil, nil)
+	NilGuard(map[string]int{}, &n)
+}
+
+func TestNames(t *testing.T) {
+	Names([]string{"b", "_a", "c"}, "", []byte("c"))
+}
+
+func TestDefer(t *testing.T) {
+	Safe(func() { panic("boom") })
+	var log []string
+	Trace(&log)
+}
+
+func TestConfig(t *testing.T) {
+	NewConfig("a")
+	Scale(2, "k")
+}
+
+func TestHistogram(t *testing.T) {
+	in := make(chan interface{}, 1)
+	in <- "a"
+	Histogram([]int{3, 1, 2}, map[int]bool{2: true}, in)
+}
+
+func TestSteps(t *testing.T) {
+	Steps(2, 3)
+}
+
+func TestClamp(t *testing.T) {
+	if Clamp(len(t.Name())) > 10 {
+		t.Error("Clamp")
+	}
+}
+
 func TestZoo1(t *testing.T) {}
 func TestZoo2(t *testing.T) {}